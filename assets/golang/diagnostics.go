@@ -0,0 +1,327 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MAX_DELAY      = 5 * time.Minute
+	MAX_BODY_SIZE  = 1 << 30
+	MAX_CHUNKS     = 10000
+	WRITE_SIZE     = 32 * 1024
+	DEFAULT_CHUNK  = 1024
+	BODY_ALPHABET  = "abcdefghijklmnopqrstuvwxyz"
+	SHA256_HEADER  = "X-Body-Sha256"
+	SESSION_COOKIE = "JSESSIONID"
+)
+
+// RequestInfo describes a request as the app received it, after any routers
+// and load balancers in front of it.
+type RequestInfo struct {
+	Method        string      `json:"method"`
+	Host          string      `json:"host"`
+	Path          string      `json:"path"`
+	RawQuery      string      `json:"raw_query"`
+	Proto         string      `json:"proto"`
+	RemoteAddr    string      `json:"remote_addr"`
+	Headers       http.Header `json:"headers"`
+	TLS           *TLSInfo    `json:"tls"`
+	InstanceIndex string      `json:"instance_index"`
+	InstanceGUID  string      `json:"instance_guid"`
+	AppName       string      `json:"app_name"`
+}
+
+type TLSInfo struct {
+	Version            string `json:"version"`
+	CipherSuite        string `json:"cipher_suite"`
+	ServerName         string `json:"server_name"`
+	NegotiatedProtocol string `json:"negotiated_protocol"`
+}
+
+func newRequestInfo(req *http.Request) RequestInfo {
+	info := RequestInfo{
+		Method:        req.Method,
+		Host:          req.Host,
+		Path:          req.URL.Path,
+		RawQuery:      req.URL.RawQuery,
+		Proto:         req.Proto,
+		RemoteAddr:    req.RemoteAddr,
+		Headers:       req.Header,
+		InstanceIndex: os.Getenv("CF_INSTANCE_INDEX"),
+		InstanceGUID:  os.Getenv("CF_INSTANCE_GUID"),
+		AppName:       appName(),
+	}
+	if req.TLS != nil {
+		info.TLS = &TLSInfo{
+			Version:            tls.VersionName(req.TLS.Version),
+			CipherSuite:        tls.CipherSuiteName(req.TLS.CipherSuite),
+			ServerName:         req.TLS.ServerName,
+			NegotiatedProtocol: req.TLS.NegotiatedProtocol,
+		}
+	}
+	return info
+}
+
+// appName is the name of the app from VCAP_APPLICATION, so that responses
+// show which of several apps sharing a host answered.
+func appName() string {
+	var app struct {
+		Name string `json:"application_name"`
+	}
+	_ = json.Unmarshal([]byte(os.Getenv("VCAP_APPLICATION")), &app)
+	return app.Name
+}
+
+func writeRequestInfo(res http.ResponseWriter, req *http.Request, statusCode int) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(statusCode)
+	encoder := json.NewEncoder(res)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(newRequestInfo(req))
+}
+
+// echoRequest responds with the request it received, as JSON, on /request and
+// on any path below it.
+func echoRequest(res http.ResponseWriter, req *http.Request) {
+	fmt.Println("Received request for", req.URL.Path, time.Now())
+	writeRequestInfo(res, req, http.StatusOK)
+}
+
+// startSession responds with the request, setting a JSESSIONID cookie when
+// the client has none. Gorouter then pins the client to this instance with
+// its __VCAP_ID__ cookie.
+func startSession(res http.ResponseWriter, req *http.Request) {
+	if _, err := req.Cookie(SESSION_COOKIE); err != nil {
+		id := make([]byte, 16)
+		_, _ = rand.Read(id)
+		http.SetCookie(res, &http.Cookie{Name: SESSION_COOKIE, Value: hex.EncodeToString(id), Path: "/"})
+	}
+	writeRequestInfo(res, req, http.StatusOK)
+}
+
+// delayResponse waits for the duration in the path, such as /delay/1500ms, before
+// responding with the request.
+func delayResponse(res http.ResponseWriter, req *http.Request) {
+	duration, err := time.ParseDuration(req.PathValue("duration"))
+	if err != nil || duration < 0 || duration > MAX_DELAY {
+		http.Error(res, fmt.Sprintf("duration must be between 0s and %s", MAX_DELAY), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case <-time.After(duration):
+	case <-req.Context().Done():
+		fmt.Println("Client went away during delay of", duration)
+		return
+	}
+	res.Header().Set("X-Delay", duration.String())
+	writeRequestInfo(res, req, http.StatusOK)
+}
+
+// respondWithStatus responds with the status code in the path, such as /status/503.
+func respondWithStatus(res http.ResponseWriter, req *http.Request) {
+	code, err := strconv.Atoi(req.PathValue("code"))
+	if err != nil || code < 200 || code > 599 {
+		http.Error(res, "code must be between 200 and 599", http.StatusBadRequest)
+		return
+	}
+	writeRequestInfo(res, req, code)
+}
+
+// sendBytes responds with a body of the size in the path, such as
+// /bytes/10485760, with a Content-Length. The body repeats the lowercase
+// alphabet, and its SHA-256 is sent in the X-Body-Sha256 header.
+func sendBytes(res http.ResponseWriter, req *http.Request) {
+	size, err := strconv.ParseInt(req.PathValue("size"), 10, 64)
+	if err != nil || size < 0 || size > MAX_BODY_SIZE {
+		http.Error(res, fmt.Sprintf("size must be between 0 and %d", MAX_BODY_SIZE), http.StatusBadRequest)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	res.Header().Set(SHA256_HEADER, bodySHA256(size))
+	_ = writeBody(res, size, nil)
+}
+
+// sendChunked responds with the number of chunks in the path, such as
+// /chunked/10, using chunked transfer encoding. Each chunk is ?size= bytes
+// (1024 by default) and is flushed ?interval= after the previous one. The
+// body is the same as /bytes would send for the total size.
+func sendChunked(res http.ResponseWriter, req *http.Request) {
+	count, err := strconv.Atoi(req.PathValue("count"))
+	if err != nil || count < 0 || count > MAX_CHUNKS {
+		http.Error(res, fmt.Sprintf("count must be between 0 and %d", MAX_CHUNKS), http.StatusBadRequest)
+		return
+	}
+	chunkSize := int64(DEFAULT_CHUNK)
+	if s := req.URL.Query().Get("size"); s != "" {
+		chunkSize, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || chunkSize < 1 || chunkSize*int64(count) > MAX_BODY_SIZE {
+			http.Error(res, fmt.Sprintf("size must be positive and the body at most %d bytes", MAX_BODY_SIZE), http.StatusBadRequest)
+			return
+		}
+	}
+	var interval time.Duration
+	if i := req.URL.Query().Get("interval"); i != "" {
+		interval, err = time.ParseDuration(i)
+		if err != nil || interval < 0 || interval*time.Duration(count) > MAX_DELAY {
+			http.Error(res, fmt.Sprintf("interval must be non-negative and the total at most %s", MAX_DELAY), http.StatusBadRequest)
+			return
+		}
+	}
+
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		http.Error(res, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set(SHA256_HEADER, bodySHA256(chunkSize*int64(count)))
+	res.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	offset := int64(0)
+	for i := 0; i < count; i++ {
+		if i > 0 && interval > 0 {
+			time.Sleep(interval)
+		}
+		if writeBody(res, chunkSize, &offset) != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// UploadInfo describes a request body as the app received it.
+type UploadInfo struct {
+	Bytes            int64    `json:"bytes"`
+	SHA256           string   `json:"sha256"`
+	ContentLength    int64    `json:"content_length"`
+	TransferEncoding []string `json:"transfer_encoding"`
+}
+
+// receiveUpload reads the request body, of at most 1GiB, and responds with
+// its size and SHA-256, and how it was framed.
+func receiveUpload(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost && req.Method != http.MethodPut {
+		http.Error(res, "upload with POST or PUT", http.StatusMethodNotAllowed)
+		return
+	}
+	hash := sha256.New()
+	n, err := io.Copy(hash, http.MaxBytesReader(res, req.Body, MAX_BODY_SIZE))
+	if err != nil {
+		http.Error(res, fmt.Sprintf("reading body after %d bytes: %s", n, err), http.StatusBadRequest)
+		return
+	}
+	fmt.Println("Received upload of", n, "bytes")
+
+	res.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(res).Encode(UploadInfo{
+		Bytes:            n,
+		SHA256:           hex.EncodeToString(hash.Sum(nil)),
+		ContentLength:    req.ContentLength,
+		TransferEncoding: req.TransferEncoding,
+	})
+}
+
+// sendGzip responds like /bytes with the size in the path, such as
+// /gzip/1048576, but gzip-compressed when the request accepts gzip. The
+// X-Body-Sha256 header is that of the uncompressed body.
+func sendGzip(res http.ResponseWriter, req *http.Request) {
+	size, err := strconv.ParseInt(req.PathValue("size"), 10, 64)
+	if err != nil || size < 0 || size > MAX_BODY_SIZE {
+		http.Error(res, fmt.Sprintf("size must be between 0 and %d", MAX_BODY_SIZE), http.StatusBadRequest)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Vary", "Accept-Encoding")
+	res.Header().Set(SHA256_HEADER, bodySHA256(size))
+	if !strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+		res.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		_ = writeBody(res, size, nil)
+		return
+	}
+
+	res.Header().Set("Content-Encoding", "gzip")
+	gz := gzip.NewWriter(res)
+	if writeBody(gz, size, nil) == nil {
+		_ = gz.Close()
+	}
+}
+
+// sendMalformed answers with bytes that are not an HTTP response, so that
+// routers have to report a bad gateway.
+func sendMalformed(res http.ResponseWriter, req *http.Request) {
+	hijacker, ok := res.(http.Hijacker)
+	if !ok {
+		http.Error(res, "hijacking is not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	_, _ = buf.WriteString("this is not an HTTP response\r\n\r\n")
+	_ = buf.Flush()
+}
+
+// writeBody writes size bytes of the repeating alphabet to w, continuing
+// from *offset when it is set.
+func writeBody(w io.Writer, size int64, offset *int64) error {
+	start := int64(0)
+	if offset != nil {
+		start = *offset
+		*offset += size
+	}
+	buff := make([]byte, WRITE_SIZE)
+	for written := int64(0); written < size; {
+		n := int64(len(buff))
+		if size-written < n {
+			n = size - written
+		}
+		fillBody(buff[:n], start+written)
+		_, err := w.Write(buff[:n])
+		if err != nil {
+			return err
+		}
+		written += n
+	}
+	return nil
+}
+
+func fillBody(buff []byte, offset int64) {
+	for i := range buff {
+		buff[i] = BODY_ALPHABET[(offset+int64(i))%int64(len(BODY_ALPHABET))]
+	}
+}
+
+func bodySHA256(size int64) string {
+	hash := sha256.New()
+	buff := make([]byte, WRITE_SIZE)
+	for written := int64(0); written < size; {
+		n := int64(len(buff))
+		if size-written < n {
+			n = size - written
+		}
+		fillBody(buff[:n], written)
+		hash.Write(buff[:n])
+		written += n
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
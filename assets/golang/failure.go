@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+)
+
+// The http_routing suite names these modes in http_routing_suite_test.go.
+const (
+	FAIL_RESET  = "reset"
+	FAIL_REFUSE = "refuse"
+)
+
+// failure makes this instance fail every request once /fail/{mode} is
+// requested, so that specs can watch routers deal with a broken backend.
+// There is no way back; the instance has to be restarted.
+type failure struct {
+	listener net.Listener
+	mode     atomic.Value
+}
+
+func (f *failure) current() string {
+	mode, _ := f.mode.Load().(string)
+	return mode
+}
+
+// start responds, and then fails all later requests with the mode in the
+// path: "reset" resets connections instead of responding, and "refuse"
+// also stops accepting connections. Refusing connections fails port health
+// checks, so apps using it should be pushed with a process health check.
+func (f *failure) start(res http.ResponseWriter, req *http.Request) {
+	mode := req.PathValue("mode")
+	if mode != FAIL_RESET && mode != FAIL_REFUSE {
+		http.Error(res, fmt.Sprintf("mode must be %q or %q", FAIL_RESET, FAIL_REFUSE), http.StatusBadRequest)
+		return
+	}
+
+	fmt.Println("Failing requests with mode", mode)
+	fmt.Fprintf(res, "instance %s failing with %s\n", os.Getenv("CF_INSTANCE_INDEX"), mode)
+	if flusher, ok := res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	f.mode.Store(mode)
+	if mode == FAIL_REFUSE {
+		_ = f.listener.Close()
+	}
+}
+
+// wrap resets the connection of every request once the instance is failing.
+// Connections accepted earlier, such as the routers' keep-alive connections,
+// are reset too.
+func (f *failure) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if f.current() == "" {
+			next.ServeHTTP(res, req)
+			return
+		}
+		resetConnection(res)
+	})
+}
+
+// resetConnection closes the connection with a TCP reset instead of a
+// response.
+func resetConnection(res http.ResponseWriter) {
+	hijacker, ok := res.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+	_ = conn.Close()
+}
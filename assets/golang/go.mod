@@ -1,3 +1,5 @@
 module github.com/cloudfoundry/routing-acceptance-tests/assets/golang
 
-go 1.14
+go 1.23
+
+require github.com/gorilla/websocket v1.5.3
@@ -1,28 +1,76 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
+const SHUTDOWN_TIMEOUT = 5 * time.Second
+
 func main() {
-	http.HandleFunc("/", hello)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", hello)
+	mux.HandleFunc("/request", echoRequest)
+	mux.HandleFunc("/request/", echoRequest)
+	mux.HandleFunc("/session", startSession)
+	mux.HandleFunc("/delay/{duration}", delayResponse)
+	mux.HandleFunc("/status/{code}", respondWithStatus)
+	mux.HandleFunc("/bytes/{size}", sendBytes)
+	mux.HandleFunc("/chunked/{count}", sendChunked)
+	mux.HandleFunc("/upload", receiveUpload)
+	mux.HandleFunc("/gzip/{size}", sendGzip)
+	mux.HandleFunc("/malformed", sendMalformed)
+	mux.HandleFunc("/websocket", websocketEcho)
+
 	port := os.Getenv("PORT")
 	fmt.Printf("Listening on %s...", port)
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		panic(err)
+	}
+	failing := &failure{listener: listener}
+	mux.HandleFunc("/fail/{mode}", failing.start)
 	server := &http.Server{
-		Addr:              fmt.Sprintf(":%s", port),
-		Handler:           nil,
+		Handler:           failing.wrap(mux),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
-	err := server.ListenAndServe()
-	if err != nil {
+
+	stopped := make(chan struct{})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		sig := <-signals
+		fmt.Printf("Received %s, shutting down\n", sig)
+		// Shutdown leaves hijacked connections alone, so close websockets first.
+		closeWebsockets(SHUTDOWN_TIMEOUT)
+		ctx, cancel := context.WithTimeout(context.Background(), SHUTDOWN_TIMEOUT)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+		close(stopped)
+	}()
+
+	err = server.Serve(listener)
+	// Refusing connections closes the listener, but the instance keeps
+	// resetting the connections it already has until it is stopped.
+	refused := errors.Is(err, net.ErrClosed) && failing.current() == FAIL_REFUSE
+	if err != nil && err != http.ErrServerClosed && !refused {
 		panic(err)
 	}
+	<-stopped
 }
 
-func hello(res http.ResponseWriter, _ *http.Request) {
+func hello(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(res, req)
+		return
+	}
 	fmt.Println("Received request ", time.Now())
 	fmt.Fprintln(res, "go, world")
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const WEBSOCKET_CLOSE_POLL_INTERVAL = 50 * time.Millisecond
+
+var upgrader = websocket.Upgrader{
+	// Tests connect from anywhere, through routers that may rewrite the host.
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// websockets holds the open websocket connections, so they can be closed
+// cleanly when the app shuts down.
+var websockets = struct {
+	sync.Mutex
+	conns map[*websocket.Conn]struct{}
+}{conns: map[*websocket.Conn]struct{}{}}
+
+func trackWebsocket(conn *websocket.Conn) {
+	websockets.Lock()
+	defer websockets.Unlock()
+	websockets.conns[conn] = struct{}{}
+}
+
+func untrackWebsocket(conn *websocket.Conn) {
+	websockets.Lock()
+	defer websockets.Unlock()
+	delete(websockets.conns, conn)
+}
+
+func openWebsockets() []*websocket.Conn {
+	websockets.Lock()
+	defer websockets.Unlock()
+	conns := make([]*websocket.Conn, 0, len(websockets.conns))
+	for conn := range websockets.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// closeWebsockets sends a going away close frame on every open websocket and
+// waits up to timeout for the clients to acknowledge it.
+func closeWebsockets(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	conns := openWebsockets()
+	for _, conn := range conns {
+		message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "app shutting down")
+		err := conn.WriteControl(websocket.CloseMessage, message, deadline)
+		if err != nil {
+			fmt.Println("Error closing websocket:", err)
+		}
+	}
+	for len(openWebsockets()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(WEBSOCKET_CLOSE_POLL_INTERVAL)
+	}
+	fmt.Printf("Closed %d websockets, %d did not acknowledge\n", len(conns), len(openWebsockets()))
+}
+
+// websocketEcho upgrades the connection and writes every message back with
+// the same message type until the client closes it.
+func websocketEcho(res http.ResponseWriter, req *http.Request) {
+	conn, err := upgrader.Upgrade(res, req, nil)
+	if err != nil {
+		fmt.Println("Error upgrading to websocket:", err)
+		return
+	}
+	defer conn.Close()
+	trackWebsocket(conn)
+	defer untrackWebsocket(conn)
+	fmt.Println("Websocket connected from", req.RemoteAddr)
+
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				fmt.Println("Websocket closed:", err)
+			}
+			return
+		}
+		err = conn.WriteMessage(messageType, message)
+		if err != nil {
+			fmt.Println("Error writing websocket message:", err)
+			return
+		}
+	}
+}
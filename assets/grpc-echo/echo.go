@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// The echo.Echo service is served by hand rather than with grpc-go. Its
+// messages are the protobuf well-known StringValue, so it needs no more than
+// the gRPC wire format over HTTP/2:
+//
+//	service Echo {
+//	  rpc Echo(google.protobuf.StringValue) returns (google.protobuf.StringValue);
+//	  rpc Chat(stream google.protobuf.StringValue) returns (stream google.protobuf.StringValue);
+//	}
+const (
+	ECHO_PATH = "/echo.Echo/Echo"
+	CHAT_PATH = "/echo.Echo/Chat"
+	// MAX_MESSAGE_SIZE is the default limit of grpc-go servers.
+	MAX_MESSAGE_SIZE = 4 * 1024 * 1024
+)
+
+// The gRPC status codes the service answers with.
+const (
+	CODE_OK                 = 0
+	CODE_INVALID_ARGUMENT   = 3
+	CODE_RESOURCE_EXHAUSTED = 8
+	CODE_UNIMPLEMENTED      = 12
+	CODE_INTERNAL           = 13
+)
+
+// statusError ends a call with a gRPC status other than OK.
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("gRPC status %d: %s", e.code, e.message)
+}
+
+var errMalformed = statusError{CODE_INVALID_ARGUMENT, "malformed google.protobuf.StringValue"}
+
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "Only gRPC calls are served", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Every call reports which server answered, and whether it reached the
+	// server over TLS, in its response headers.
+	transport := "h2c"
+	if r.TLS != nil {
+		transport = "tls"
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Server-Id", *serverId)
+	w.Header().Set("Transport", transport)
+
+	var err error
+	switch r.URL.Path {
+	case ECHO_PATH:
+		err = echo(w, r.Body)
+	case CHAT_PATH:
+		err = chat(w, r.Body)
+	default:
+		err = statusError{CODE_UNIMPLEMENTED, "unknown method " + r.URL.Path}
+	}
+
+	code := CODE_OK
+	if err != nil {
+		fmt.Printf("%s:%s failed: %s\n", *serverId, r.URL.Path, err)
+		code = CODE_INTERNAL
+		message := err.Error()
+		var status statusError
+		if errors.As(err, &status) {
+			code, message = status.code, status.message
+		}
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", message)
+	}
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+}
+
+func echo(w http.ResponseWriter, body io.Reader) error {
+	in, err := readMessage(body)
+	if err == io.EOF {
+		return statusError{CODE_INVALID_ARGUMENT, "no request message"}
+	}
+	if err != nil {
+		return err
+	}
+	_, err = readMessage(body)
+	if err != io.EOF {
+		return statusError{CODE_INVALID_ARGUMENT, "more than one request message"}
+	}
+	fmt.Printf("%s:Echo %d bytes\n", *serverId, len(in))
+	return writeMessage(w, in)
+}
+
+// chat echoes every message on the stream in the order it arrives, until the
+// client closes its side.
+func chat(w http.ResponseWriter, body io.Reader) error {
+	// Send the headers straight away, as a client may wait for them before
+	// it sends anything.
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	messages := 0
+	for {
+		in, err := readMessage(body)
+		if err == io.EOF {
+			fmt.Printf("%s:Chat echoed %d messages\n", *serverId, messages)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = writeMessage(w, in)
+		if err != nil {
+			return err
+		}
+		messages++
+	}
+}
+
+// readMessage reads one length-prefixed message and returns its value. It
+// returns io.EOF when the client has closed its side between messages.
+func readMessage(body io.Reader) (string, error) {
+	var prefix [5]byte
+	_, err := io.ReadFull(body, prefix[:])
+	if err == io.EOF {
+		return "", io.EOF
+	}
+	if err != nil {
+		return "", statusError{CODE_INTERNAL, "reading message: " + err.Error()}
+	}
+	if prefix[0] != 0 {
+		return "", statusError{CODE_UNIMPLEMENTED, "compressed messages are not supported"}
+	}
+	length := binary.BigEndian.Uint32(prefix[1:])
+	if length > MAX_MESSAGE_SIZE {
+		return "", statusError{CODE_RESOURCE_EXHAUSTED, fmt.Sprintf("message of %d bytes is larger than %d", length, MAX_MESSAGE_SIZE)}
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(body, payload)
+	if err != nil {
+		return "", statusError{CODE_INTERNAL, "reading message: " + err.Error()}
+	}
+	return decodeStringValue(payload)
+}
+
+func writeMessage(w http.ResponseWriter, value string) error {
+	payload := encodeStringValue(value)
+	message := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(message[1:], uint32(len(payload)))
+	_, err := w.Write(append(message, payload...))
+	if err != nil {
+		return err
+	}
+	w.(http.Flusher).Flush()
+	return nil
+}
+
+// decodeStringValue returns the value field of an encoded StringValue,
+// skipping any fields it does not know.
+func decodeStringValue(b []byte) (string, error) {
+	var value string
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return "", errMalformed
+		}
+		b = b[n:]
+
+		var skip int
+		switch tag & 7 {
+		case 0: // varint
+			_, skip = binary.Uvarint(b)
+			if skip <= 0 {
+				return "", errMalformed
+			}
+		case 1: // 64-bit
+			skip = 8
+		case 2: // length-delimited
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return "", errMalformed
+			}
+			if tag>>3 == 1 {
+				value = string(b[n : n+int(length)])
+			}
+			skip = n + int(length)
+		case 5: // 32-bit
+			skip = 4
+		default:
+			return "", errMalformed
+		}
+		if skip > len(b) {
+			return "", errMalformed
+		}
+		b = b[skip:]
+	}
+	return value, nil
+}
+
+// encodeStringValue encodes value as field 1 of a StringValue, which proto3
+// leaves out when it is empty.
+func encodeStringValue(value string) []byte {
+	if value == "" {
+		return nil
+	}
+	b := binary.AppendUvarint([]byte{0x0a}, uint64(len(value)))
+	return append(b, value...)
+}
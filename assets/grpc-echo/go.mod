@@ -0,0 +1,12 @@
+module github.com/cloudfoundry/routing-acceptance-tests/assets/grpc-echo
+
+go 1.23
+
+require (
+	github.com/cloudfoundry/routing-acceptance-tests/assets/certs v0.0.0
+	golang.org/x/net v0.35.0
+)
+
+require golang.org/x/text v0.22.0 // indirect
+
+replace github.com/cloudfoundry/routing-acceptance-tests/assets/certs => ../certs
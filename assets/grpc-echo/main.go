@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry/routing-acceptance-tests/assets/certs"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var address = flag.String(
+	"address",
+	"",
+	"The address to serve gRPC on in cleartext HTTP/2 (h2c). Defaults to 0.0.0.0:$PORT.",
+)
+
+var tlsAddress = flag.String(
+	"tlsAddress",
+	"",
+	"An additional address to serve gRPC on over TLS, with a self-signed certificate.",
+)
+
+var tlsSAN = flag.String(
+	"tlsSAN",
+	"",
+	"Comma-separated DNS names or IP addresses to include in the self-signed certificate.",
+)
+
+var serverId = flag.String(
+	"serverId",
+	"",
+	"The server id reported in the response headers of every call.",
+)
+
+func main() {
+	flag.Parse()
+
+	if *address == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		*address = "0.0.0.0:" + port
+	}
+
+	errs := make(chan error, 2)
+	go func() {
+		errs <- serve(&http.Server{
+			Addr:    *address,
+			Handler: h2c.NewHandler(http.HandlerFunc(echoHandler), &http2.Server{}),
+		})
+	}()
+
+	if *tlsAddress != "" {
+		sans := []string{"localhost", "127.0.0.1"}
+		if *tlsSAN != "" {
+			sans = append(sans, strings.Split(*tlsSAN, ",")...)
+		}
+		cert, err := certs.Generate(*serverId, sans)
+		if err != nil {
+			fmt.Println("Error generating certificate:", err.Error())
+			os.Exit(1)
+		}
+		// http.Server negotiates HTTP/2 over TLS by itself.
+		server := &http.Server{
+			Addr:    *tlsAddress,
+			Handler: http.HandlerFunc(echoHandler),
+			TLSConfig: &tls.Config{
+				Certificates: []tls.Certificate{cert},
+				MinVersion:   tls.VersionTLS12,
+			},
+		}
+		go func() {
+			errs <- serve(server)
+		}()
+	}
+
+	err := <-errs
+	fmt.Println("Error serving gRPC:", err.Error())
+	os.Exit(1)
+}
+
+func serve(server *http.Server) error {
+	fmt.Printf("%s:Serving gRPC on %s\n", *serverId, server.Addr)
+	if server.TLSConfig != nil {
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
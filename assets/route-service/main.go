@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	FORWARDED_URL_HEADER = "X-Cf-Forwarded-Url"
+	SIGNATURE_HEADER     = "X-Cf-Proxy-Signature"
+	METADATA_HEADER      = "X-Cf-Proxy-Metadata"
+	ROUTE_SERVICE_HEADER = "X-Rats-Route-Service"
+	SEEN_URL_HEADER      = "X-Rats-Route-Service-Forwarded-Url"
+	TAMPER_HEADER        = "X-Rats-Tamper-Signature"
+)
+
+var serverId = flag.String(
+	"serverId",
+	"route-service",
+	"The id added to every forwarded request and its response.",
+)
+
+var skipSSLValidation = flag.Bool(
+	"skipSSLValidation",
+	false,
+	"Skip certificate validation when forwarding requests back through Gorouter.",
+)
+
+var routeServiceHeaders = []string{FORWARDED_URL_HEADER, SIGNATURE_HEADER, METADATA_HEADER}
+
+func main() {
+	flag.Parse()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: *skipSSLValidation}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite:   rewrite,
+		Transport: transport,
+		ModifyResponse: func(resp *http.Response) error {
+			resp.Header.Set(ROUTE_SERVICE_HEADER, *serverId)
+			resp.Header.Set(SEEN_URL_HEADER, resp.Request.Header.Get(FORWARDED_URL_HEADER))
+			return nil
+		},
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	fmt.Printf("%s:Listening on %s\n", *serverId, port)
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler(proxy),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	err := server.ListenAndServe()
+	if err != nil {
+		fmt.Println("Error serving:", err.Error())
+		os.Exit(1)
+	}
+}
+
+// handler forwards requests that carry every route service header, and
+// rejects requests that only carry some of them. Requests without any are
+// not from Gorouter, and are answered directly.
+func handler(proxy http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var missing []string
+		for _, header := range routeServiceHeaders {
+			if req.Header.Get(header) == "" {
+				missing = append(missing, header)
+			}
+		}
+
+		switch len(missing) {
+		case len(routeServiceHeaders):
+			fmt.Fprintf(res, "%s ready\n", *serverId)
+		case 0:
+			forwardedURL, err := url.Parse(req.Header.Get(FORWARDED_URL_HEADER))
+			if err != nil || !forwardedURL.IsAbs() {
+				http.Error(res, fmt.Sprintf("%s is not an absolute URL", FORWARDED_URL_HEADER), http.StatusBadRequest)
+				return
+			}
+			fmt.Printf("%s:Forwarding %s %s\n", *serverId, req.Method, forwardedURL)
+			proxy.ServeHTTP(res, req)
+		default:
+			fmt.Printf("%s:Rejecting request missing %s\n", *serverId, strings.Join(missing, ", "))
+			http.Error(res, "missing route service headers: "+strings.Join(missing, ", "), http.StatusBadRequest)
+		}
+	})
+}
+
+// rewrite sends the request on to the URL Gorouter asked for, with the
+// signature and metadata Gorouter needs to accept it back.
+func rewrite(r *httputil.ProxyRequest) {
+	forwardedURL, _ := url.Parse(r.In.Header.Get(FORWARDED_URL_HEADER))
+	r.Out.URL = forwardedURL
+	r.Out.Host = forwardedURL.Host
+	r.SetXForwarded()
+	r.Out.Header.Set(ROUTE_SERVICE_HEADER, *serverId)
+
+	if r.In.Header.Get(TAMPER_HEADER) != "" {
+		fmt.Printf("%s:Tampering with the signature\n", *serverId)
+		r.Out.Header.Set(SIGNATURE_HEADER, tamper(r.In.Header.Get(SIGNATURE_HEADER)))
+	}
+}
+
+// tamper changes one character of the signature, keeping it well-formed
+// base64 so that only its validation can reject it.
+func tamper(signature string) string {
+	b := []byte(signature)
+	i := len(b) / 2
+	if b[i] == 'A' {
+		b[i] = 'B'
+	} else {
+		b[i] = 'A'
+	}
+	return string(b)
+}
@@ -1,3 +0,0 @@
-module github.com/cloudfoundry/routing-acceptance-tests/assets/tcp-droplet-receiver
-
-go 1.14
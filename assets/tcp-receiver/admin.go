@@ -0,0 +1,260 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	CRASH_DELAY = 100 * time.Millisecond
+	// ADMIN_TOKEN_ENV names the variable holding the token every admin
+	// request must send in ADMIN_TOKEN_HEADER. Without it, only GET /ready
+	// is served.
+	ADMIN_TOKEN_ENV    = "RECEIVER_ADMIN_TOKEN"
+	ADMIN_TOKEN_HEADER = "X-Receiver-Admin-Token"
+)
+
+// faults holds the behavior changes requested through the admin API, along
+// with the listeners and connections they act on.
+type faults struct {
+	sync.Mutex
+	ready     bool
+	refusing  bool
+	latency   time.Duration
+	listeners map[string]net.Listener
+	active    map[*connection]struct{}
+}
+
+var state = &faults{
+	ready:     true,
+	listeners: map[string]net.Listener{},
+	active:    map[*connection]struct{}{},
+}
+
+// adminState is the response to GET /state.
+type adminState struct {
+	Ready             bool     `json:"ready"`
+	Refusing          bool     `json:"refusing"`
+	Latency           string   `json:"latency"`
+	Listeners         []string `json:"listeners"`
+	ActiveConnections int      `json:"active_connections"`
+}
+
+func (f *faults) addListener(address string, listener net.Listener) {
+	f.Lock()
+	defer f.Unlock()
+	f.listeners[address] = listener
+}
+
+func (f *faults) removeListener(address string) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.listeners, address)
+}
+
+func (f *faults) track(c *connection) {
+	f.Lock()
+	defer f.Unlock()
+	f.active[c] = struct{}{}
+}
+
+func (f *faults) untrack(c *connection) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.active, c)
+}
+
+func (f *faults) connections() []*connection {
+	f.Lock()
+	defer f.Unlock()
+	conns := make([]*connection, 0, len(f.active))
+	for c := range f.active {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+func (f *faults) setReady(ready bool) {
+	f.Lock()
+	defer f.Unlock()
+	f.ready = ready
+}
+
+// closeListeners stops accepting connections on every address.
+func (f *faults) closeListeners() {
+	f.Lock()
+	defer f.Unlock()
+	for _, listener := range f.listeners {
+		listener.Close()
+	}
+}
+
+func (f *faults) isRefusing() bool {
+	f.Lock()
+	defer f.Unlock()
+	return f.refusing
+}
+
+func (f *faults) responseLatency() time.Duration {
+	f.Lock()
+	defer f.Unlock()
+	return f.latency
+}
+
+func (f *faults) snapshot() adminState {
+	f.Lock()
+	defer f.Unlock()
+	s := adminState{
+		Ready:             f.ready,
+		Refusing:          f.refusing,
+		Latency:           f.latency.String(),
+		Listeners:         []string{},
+		ActiveConnections: len(f.active),
+	}
+	for address := range f.listeners {
+		s.Listeners = append(s.Listeners, address)
+	}
+	sort.Strings(s.Listeners)
+	return s
+}
+
+// reset closes conn with a TCP RST instead of an orderly FIN, the way a
+// crashed or overloaded backend would.
+func reset(conn net.Conn) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+	conn.Close()
+}
+
+func adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /state", func(w http.ResponseWriter, r *http.Request) {
+		writeState(w)
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(metrics.report())
+	})
+	// GET /ready serves as the app's HTTP readiness health check, so that
+	// Diego stops routing to the instance while it is not ready.
+	mux.HandleFunc("GET /ready", func(w http.ResponseWriter, r *http.Request) {
+		if !state.snapshot().Ready {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ready")
+	})
+	mux.HandleFunc("PUT /ready", func(w http.ResponseWriter, r *http.Request) {
+		ready, err := strconv.ParseBool(r.URL.Query().Get("ready"))
+		if err != nil {
+			http.Error(w, "ready must be true or false", http.StatusBadRequest)
+			return
+		}
+		state.setReady(ready)
+		fmt.Printf("%s:Admin set ready=%t\n", *serverId, ready)
+		writeState(w)
+	})
+	mux.HandleFunc("PUT /refuse", func(w http.ResponseWriter, r *http.Request) {
+		refusing, err := strconv.ParseBool(r.URL.Query().Get("refuse"))
+		if err != nil {
+			http.Error(w, "refuse must be true or false", http.StatusBadRequest)
+			return
+		}
+		state.Lock()
+		state.refusing = refusing
+		state.Unlock()
+		fmt.Printf("%s:Admin set refuse=%t\n", *serverId, refusing)
+		writeState(w)
+	})
+	mux.HandleFunc("PUT /latency", func(w http.ResponseWriter, r *http.Request) {
+		latency, err := time.ParseDuration(r.URL.Query().Get("latency"))
+		if err != nil || latency < 0 {
+			http.Error(w, "latency must be a non-negative duration such as 500ms", http.StatusBadRequest)
+			return
+		}
+		state.Lock()
+		state.latency = latency
+		state.Unlock()
+		fmt.Printf("%s:Admin set latency=%s\n", *serverId, latency)
+		writeState(w)
+	})
+	mux.HandleFunc("DELETE /listeners", func(w http.ResponseWriter, r *http.Request) {
+		address := r.URL.Query().Get("address")
+		state.Lock()
+		listener, ok := state.listeners[address]
+		state.Unlock()
+		if !ok {
+			http.Error(w, fmt.Sprintf("not listening on %q", address), http.StatusNotFound)
+			return
+		}
+		// launchServer removes the listener once Accept fails.
+		listener.Close()
+		fmt.Printf("%s:Admin closed listener %s\n", *serverId, address)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /connections", func(w http.ResponseWriter, r *http.Request) {
+		conns := state.connections()
+		for _, c := range conns {
+			reset(c.raw)
+		}
+		fmt.Printf("%s:Admin reset %d connections\n", *serverId, len(conns))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]int{"reset": len(conns)})
+	})
+	mux.HandleFunc("POST /crash", func(w http.ResponseWriter, r *http.Request) {
+		exitCode := 1
+		if code := r.URL.Query().Get("exit_code"); code != "" {
+			var err error
+			exitCode, err = strconv.Atoi(code)
+			if err != nil {
+				http.Error(w, "exit_code must be an integer", http.StatusBadRequest)
+				return
+			}
+		}
+		fmt.Printf("%s:Admin crashing with exit code %d\n", *serverId, exitCode)
+		w.WriteHeader(http.StatusAccepted)
+		// Give the response a chance to reach the client before exiting.
+		go func() {
+			time.Sleep(CRASH_DELAY)
+			os.Exit(exitCode)
+		}()
+	})
+	return requireToken(os.Getenv(ADMIN_TOKEN_ENV), mux)
+}
+
+// requireToken rejects requests that do not carry token, except GET /ready:
+// health checks cannot send headers, and it changes nothing.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/ready" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(ADMIN_TOKEN_HEADER)), []byte(token)) != 1 {
+			http.Error(w, fmt.Sprintf("%s does not match $%s", ADMIN_TOKEN_HEADER, ADMIN_TOKEN_ENV), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeState(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(state.snapshot())
+}
+
+// serveAdmin serves the admin API on listener until it is closed.
+func serveAdmin(listener net.Listener, wg *sync.WaitGroup) {
+	defer wg.Done()
+	err := http.Serve(listener, adminHandler())
+	fmt.Println("Error serving admin API:", err.Error())
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// fingerprint is the hex SHA-256 of a DER certificate, as identifying
+// responses report it.
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const DRAIN_POLL_INTERVAL = 50 * time.Millisecond
+
+var errDrained = errors.New("drained")
+
+var (
+	draining         atomic.Bool
+	drainedExchanges atomic.Int64
+)
+
+func isDraining() bool {
+	return draining.Load()
+}
+
+// drain stops accepting connections, lets every exchange in progress finish
+// within timeout and then exits. Idle connections are closed straight away,
+// and whatever is still open at the deadline is closed forcibly.
+func drain(reason string, timeout time.Duration) {
+	fmt.Printf("%s:Received %s, draining for up to %s\n", *serverId, reason, timeout)
+	start := time.Now()
+	draining.Store(true)
+	state.setReady(false)
+	state.closeListeners()
+
+	idleClosed := 0
+	for _, c := range state.connections() {
+		if c.closeIfIdle() {
+			idleClosed++
+		}
+	}
+
+	deadline := start.Add(timeout)
+	for len(state.connections()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(DRAIN_POLL_INTERVAL)
+	}
+
+	remaining := state.connections()
+	for _, c := range remaining {
+		c.raw.Close()
+	}
+
+	fmt.Printf("%s:Drained in %s: completed_exchanges=%d idle_closed=%d forcibly_closed=%d\n",
+		*serverId, time.Since(start).Round(time.Millisecond), drainedExchanges.Load(), idleClosed, len(remaining))
+	os.Exit(0)
+}
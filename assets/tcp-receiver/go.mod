@@ -1,19 +1,24 @@
-module github.com/cloudfoundry/routing-acceptance-tests/assets/tcp-sample-receiver
+module github.com/cloudfoundry/routing-acceptance-tests/assets/tcp-receiver
 
 go 1.23
 
-require github.com/tedsuo/ifrit v0.0.0-20230516164442-7862c310ad26
+require (
+	github.com/cloudfoundry/routing-acceptance-tests/assets/certs v0.0.0
+	github.com/onsi/ginkgo/v2 v2.9.4
+	github.com/onsi/gomega v1.27.6
+	github.com/tedsuo/ifrit v0.0.0-20230516164442-7862c310ad26
+)
 
 require (
 	github.com/go-logr/logr v1.2.4 // indirect
 	github.com/go-task/slim-sprig v0.0.0-20230315185526-52ccab3ef572 // indirect
 	github.com/google/go-cmp v0.5.9 // indirect
 	github.com/google/pprof v0.0.0-20210720184732-4bb14d4b1be1 // indirect
-	github.com/onsi/ginkgo/v2 v2.9.4 // indirect
-	github.com/onsi/gomega v1.27.6 // indirect
 	golang.org/x/net v0.10.0 // indirect
 	golang.org/x/sys v0.8.0 // indirect
 	golang.org/x/text v0.9.0 // indirect
 	golang.org/x/tools v0.8.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/cloudfoundry/routing-acceptance-tests/assets/certs => ../certs
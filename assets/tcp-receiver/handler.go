@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// connection is an accepted connection after any PROXY protocol header has
+// been read and any TLS handshake completed.
+type connection struct {
+	net.Conn
+	// raw is the accepted connection, before any PROXY protocol or TLS
+	// wrapping.
+	raw           net.Conn
+	listenAddress string
+	clientAddr    net.Addr
+	identity      string
+	// sni and alpn are only set on TLS connections.
+	sni  string
+	alpn string
+
+	// mu guards the exchange state that draining depends on.
+	mu       sync.Mutex
+	busy     bool
+	answered int
+}
+
+// identityResponse is the response to each message with -format=json.
+type identityResponse struct {
+	ServerID      string `json:"server_id"`
+	ListenAddress string `json:"listen_address"`
+	InstanceIndex *int   `json:"instance_index"`
+	InstanceGUID  string `json:"instance_guid"`
+	InstanceIP    string `json:"instance_ip"`
+	ClientAddress string `json:"client_address"`
+	Bytes         int    `json:"bytes"`
+	Message       string `json:"message"`
+	SNI           string `json:"sni,omitempty"`
+	ALPN          string `json:"alpn,omitempty"`
+	CertSHA256    string `json:"cert_sha256,omitempty"`
+}
+
+// handlerFunc serves a connection until it fails or should be closed, and
+// returns the reason it stopped.
+type handlerFunc func(c *connection) error
+
+var modes = map[string]handlerFunc{
+	"echo":        handleEcho,
+	"identify":    handleIdentify,
+	"delay":       handleDelay,
+	"close-after": handleCloseAfter,
+	"greeting":    handleGreeting,
+	"stream":      handleStream,
+}
+
+func modeNames() []string {
+	names := make([]string, 0, len(modes))
+	for name := range modes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// identify builds the response identifying this server for message.
+func (c *connection) identify(message []byte) []byte {
+	if *format == FORMAT_JSON {
+		return c.identifyJSON(message)
+	}
+
+	var writeBuffer bytes.Buffer
+	writeBuffer.WriteString(c.identity)
+	writeBuffer.WriteString(":")
+	writeBuffer.Write(message)
+	if *reportClientAddress {
+		writeBuffer.WriteString(" client_address=" + c.clientAddr.String())
+	}
+	if tlsConfig != nil {
+		fmt.Fprintf(&writeBuffer, " sni=%s alpn=%s cert_sha256=%s", c.sni, c.alpn, tlsFingerprint)
+	}
+	return writeBuffer.Bytes()
+}
+
+// identifyJSON is identify for -format=json. The client address is always
+// included, and the instance fields come from the CF_INSTANCE_* variables.
+func (c *connection) identifyJSON(message []byte) []byte {
+	response := identityResponse{
+		ServerID:      *serverId,
+		ListenAddress: c.listenAddress,
+		InstanceIndex: instance.index,
+		InstanceGUID:  instance.guid,
+		InstanceIP:    instance.ip,
+		ClientAddress: c.clientAddr.String(),
+		Bytes:         len(message),
+		Message:       string(message),
+		SNI:           c.sni,
+		ALPN:          c.alpn,
+	}
+	if tlsConfig != nil {
+		response.CertSHA256 = tlsFingerprint
+	}
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		// Every field is a plain string or number, so this cannot happen.
+		panic(err)
+	}
+	return encoded
+}
+
+// respond reads messages and writes back respond(message) for each, until
+// limit messages have been answered or, with a limit of 0, forever. While
+// draining it stops after the exchange in progress.
+func (c *connection) respond(limit int, response func(message []byte) []byte) error {
+	// Make a buffer to hold incoming data.
+	buff := make([]byte, BUFFER_SIZE)
+	for i := 0; limit == 0 || i < limit; i++ {
+		// Read the incoming connection into the buffer.
+		readBytes, err := c.Read(buff)
+		if err != nil {
+			if isDraining() {
+				return errDrained
+			}
+			return err
+		}
+		c.setBusy(true)
+		writeBuffer := response(buff[0:readBytes])
+		if latency := state.responseLatency(); latency > 0 {
+			time.Sleep(latency)
+		}
+		logf("Message to %s: %s\n", c.clientAddr, writeBuffer)
+		_, err = c.Write(writeBuffer)
+		c.setBusy(false)
+		if err != nil {
+			return err
+		}
+		metrics.each(c.listenAddress, func(c *counters) { c.messages.Add(1) })
+		if isDraining() {
+			return errDrained
+		}
+	}
+	return fmt.Errorf("answered %d messages", limit)
+}
+
+func (c *connection) setBusy(busy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy = busy
+	if !busy {
+		c.answered++
+		if isDraining() {
+			drainedExchanges.Add(1)
+		}
+	}
+}
+
+// closeIfIdle interrupts a connection that is waiting for its next message,
+// and reports whether it did. Connections that have not answered a message
+// yet are left to complete their first exchange.
+func (c *connection) closeIfIdle() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.busy || c.answered == 0 {
+		return false
+	}
+	return c.SetReadDeadline(time.Now()) == nil
+}
+
+// handleEcho writes every message back unchanged.
+func handleEcho(c *connection) error {
+	return c.respond(0, func(message []byte) []byte {
+		return message
+	})
+}
+
+// handleIdentify prefixes every message with the server id.
+func handleIdentify(c *connection) error {
+	return c.respond(0, c.identify)
+}
+
+// handleDelay identifies every message after waiting for -delay.
+func handleDelay(c *connection) error {
+	return c.respond(0, func(message []byte) []byte {
+		time.Sleep(*delay)
+		return c.identify(message)
+	})
+}
+
+// handleCloseAfter identifies -closeAfter messages and then closes the
+// connection.
+func handleCloseAfter(c *connection) error {
+	return c.respond(*closeAfter, c.identify)
+}
+
+// handleGreeting sends the -greeting banner as soon as the connection is
+// accepted, before the client has written anything, and then identifies
+// every message.
+func handleGreeting(c *connection) error {
+	banner := *greeting + "\r\n"
+	logf("Greeting %s: %s", c.clientAddr, banner)
+	_, err := io.WriteString(c, banner)
+	if err != nil {
+		return err
+	}
+	return c.respond(0, c.identify)
+}
+
+// handleStream copies everything it reads straight back, without any
+// per-message processing, until the client stops sending.
+func handleStream(c *connection) error {
+	n, err := io.Copy(c, c)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("streamed %d bytes", n)
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry/routing-acceptance-tests/assets/certs"
+	"github.com/cloudfoundry/routing-acceptance-tests/assets/tcp-receiver/proxyproto"
+)
+
+const (
+	DEFAULT_ADDRESS     = "0.0.0.0:3333"
+	CONN_TYPE           = "tcp"
+	DEFAULT_SERVER_ID   = "tcp_receiver"
+	DEFAULT_MODE        = "identify"
+	FORMAT_TEXT         = "text"
+	FORMAT_JSON         = "json"
+	DEFAULT_DELAY       = 1 * time.Second
+	DEFAULT_CLOSE_AFTER = 1
+	DEFAULT_TLS_SAN     = "localhost"
+	SUMMARY_INTERVAL    = 10 * time.Second
+	MAX_ACCEPT_DELAY    = 1 * time.Second
+	HEADER_TIMEOUT      = 5 * time.Second
+	// Diego kills a container 10 seconds after asking it to stop.
+	DEFAULT_DRAIN_TIMEOUT = 8 * time.Second
+	BUFFER_SIZE           = 1024
+)
+
+var serverAddress = flag.String(
+	"address",
+	DEFAULT_ADDRESS,
+	"Comma separated addresses in host:port format that the server will bind to. With more than one address, identifying responses include the address that received the message. The receiver never echoes on $PORT: when $PORT is set, only the admin API is served on 0.0.0.0:$PORT, and an address on $PORT is an error.",
+)
+
+var serverId = flag.String(
+	"serverId",
+	DEFAULT_SERVER_ID,
+	"The Server id that is echoed back for each message.",
+)
+
+var mode = flag.String(
+	"mode",
+	DEFAULT_MODE,
+	"How to respond on every connection: one of "+strings.Join(modeNames(), ", ")+".",
+)
+
+var format = flag.String(
+	"format",
+	FORMAT_TEXT,
+	"How identifying responses are written: text (<serverId>:<message> followed by any name=value fields) or json (an object that also includes the listening address, the CF_INSTANCE_INDEX, CF_INSTANCE_GUID and CF_INSTANCE_IP of the instance, the client address and the message length).",
+)
+
+var drainTimeout = flag.Duration(
+	"drainTimeout",
+	DEFAULT_DRAIN_TIMEOUT,
+	"How long to let in-flight exchanges finish after SIGTERM or SIGINT before closing the remaining connections and exiting.",
+)
+
+var latency = flag.Duration(
+	"latency",
+	0,
+	"How long to delay every response, in any mode that responds to messages. The admin API can change it at runtime.",
+)
+
+var delay = flag.Duration(
+	"delay",
+	DEFAULT_DELAY,
+	"How long to wait before each response in delay mode.",
+)
+
+var closeAfter = flag.Int(
+	"closeAfter",
+	DEFAULT_CLOSE_AFTER,
+	"How many messages to respond to before closing the connection in close-after mode.",
+)
+
+var greeting = flag.String(
+	"greeting",
+	"",
+	"The banner sent on accept in greeting mode. Defaults to \"220 <serverId> ready\".",
+)
+
+var quiet = flag.Bool(
+	"quiet",
+	false,
+	"Suppress per-connection logging and periodically log connection counts instead.",
+)
+
+var proxyProtocol = flag.Bool(
+	"proxyProtocol",
+	false,
+	"Accept a PROXY protocol v1 or v2 header at the start of each connection. In greeting mode the header is required.",
+)
+
+var reportClientAddress = flag.Bool(
+	"reportClientAddress",
+	false,
+	"Append the observed client address to each identifying response as client_address=<host:port>.",
+)
+
+var tlsEnabled = flag.Bool(
+	"tls",
+	false,
+	"Terminate TLS on every listener with a generated self-signed certificate. Identifying responses then report the SNI, the negotiated ALPN protocol and the certificate fingerprint.",
+)
+
+var tlsSAN = flag.String(
+	"tlsSAN",
+	DEFAULT_TLS_SAN,
+	"Comma separated DNS names or IP addresses to include as SANs in the generated certificate.",
+)
+
+var (
+	tlsConfig      *tls.Config
+	tlsFingerprint string
+
+	instance instanceMetadata
+)
+
+// instanceMetadata describes the app instance this server runs as, when it
+// runs on Cloud Foundry.
+type instanceMetadata struct {
+	index *int
+	guid  string
+	ip    string
+}
+
+func main() {
+	flag.Parse()
+	handler, ok := modes[*mode]
+	if !ok {
+		fmt.Printf("Unknown mode %q, expected one of %s\n", *mode, strings.Join(modeNames(), ", "))
+		os.Exit(1)
+	}
+	if *format != FORMAT_TEXT && *format != FORMAT_JSON {
+		fmt.Printf("Unknown format %q, expected %s or %s\n", *format, FORMAT_TEXT, FORMAT_JSON)
+		os.Exit(1)
+	}
+	if *closeAfter < 1 {
+		fmt.Println("closeAfter must be at least 1")
+		os.Exit(1)
+	}
+	if *greeting == "" {
+		*greeting = fmt.Sprintf("220 %s ready", *serverId)
+	}
+	if *tlsEnabled {
+		configureTLS()
+	}
+	instance = loadInstanceMetadata()
+	state.latency = *latency
+
+	addresses := strings.Split(*serverAddress, ",")
+	includeServerAddress := len(addresses) > 1
+	port := os.Getenv("PORT")
+	if port != "" && listensOnPort(addresses, port) {
+		fmt.Printf("Cannot listen on $PORT %s, which is reserved for the admin API\n", port)
+		os.Exit(1)
+	}
+
+	// Bind every address before reporting any of them, so that a
+	// "Listening on" line means the server is fully up.
+	listeners := make([]net.Listener, len(addresses))
+	for i, address := range addresses {
+		listener, err := net.Listen(CONN_TYPE, address)
+		if err != nil {
+			fmt.Println("Error listening:", err.Error())
+			os.Exit(1)
+		}
+		listeners[i] = listener
+		state.addListener(address, listener)
+		metrics.listener(address)
+	}
+
+	var adminAddress string
+	var adminListener net.Listener
+	if port != "" {
+		adminAddress = "0.0.0.0:" + port
+		var err error
+		adminListener, err = net.Listen(CONN_TYPE, adminAddress)
+		if err != nil {
+			fmt.Println("Error listening:", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	if *quiet {
+		go logSummary()
+	}
+	wg := sync.WaitGroup{}
+	if adminListener != nil {
+		fmt.Printf("%s:Serving admin API on %s\n", *serverId, adminAddress)
+		wg.Add(1)
+		go serveAdmin(adminListener, &wg)
+	}
+	for i, listener := range listeners {
+		fmt.Printf("%s:Listening on %s in %s mode\n", *serverId, addresses[i], *mode)
+		wg.Add(1)
+		go launchServer(listener, addresses[i], includeServerAddress, handler, &wg)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case sig := <-signals:
+		drain(sig.String(), *drainTimeout)
+	case <-stopped:
+	}
+}
+
+func listensOnPort(addresses []string, port string) bool {
+	for _, address := range addresses {
+		_, p, err := net.SplitHostPort(address)
+		if err == nil && p == port {
+			return true
+		}
+	}
+	return false
+}
+
+func launchServer(listener net.Listener, address string, includeServerAddress bool, handler handlerFunc, wg *sync.WaitGroup) {
+	defer wg.Done()
+	// Close the listener when the application closes.
+	defer listener.Close()
+	defer state.removeListener(address)
+	var acceptDelay time.Duration
+	for {
+		// Listen for an incoming connection.
+		conn, err := listener.Accept()
+		if err != nil {
+			if isDraining() {
+				return
+			}
+			// Running out of file descriptors under load is recoverable, so
+			// back off instead of giving up on the listener.
+			if isTemporary(err) {
+				acceptDelay = nextAcceptDelay(acceptDelay)
+				fmt.Printf("Error accepting: %s; retrying in %s\n", err.Error(), acceptDelay)
+				time.Sleep(acceptDelay)
+				continue
+			}
+			fmt.Println("Error accepting: ", err.Error())
+			return
+		}
+		acceptDelay = 0
+		if state.isRefusing() {
+			logf("Refusing connection from %s\n", conn.RemoteAddr())
+			metrics.each(address, func(c *counters) { c.refused.Add(1) })
+			reset(conn)
+			continue
+		}
+		// Handle connections in a new goroutine.
+		go handleRequest(conn, address, includeServerAddress, handler)
+	}
+}
+
+// Handles incoming requests.
+func handleRequest(conn net.Conn, address string, includeServerAddress bool, handler handlerFunc) {
+	metrics.each(address, func(c *counters) {
+		c.accepted.Add(1)
+		c.active.Add(1)
+	})
+	defer metrics.each(address, func(c *counters) { c.active.Add(-1) })
+	// Close the connection when you're done with it.
+	defer conn.Close()
+
+	c := &connection{
+		Conn:          &countingConn{Conn: conn, address: address},
+		raw:           conn,
+		listenAddress: address,
+		clientAddr:    conn.RemoteAddr(),
+	}
+	state.track(c)
+	defer state.untrack(c)
+	if includeServerAddress {
+		c.identity = fmt.Sprintf("%s(%s)", *serverId, address)
+	} else {
+		c.identity = *serverId
+	}
+	logf("Remote Address: %s\n", c.clientAddr)
+
+	if *proxyProtocol {
+		proxyConn, err := readProxyHeader(c.Conn)
+		if err != nil {
+			logf("Closing connection to %s: %s\n", c.clientAddr, err.Error())
+			return
+		}
+		c.Conn = proxyConn
+		c.clientAddr = proxyConn.ClientAddr()
+		logf("Client Address: %s\n", c.clientAddr)
+	}
+
+	if tlsConfig != nil {
+		tlsConn, err := handshake(c.Conn)
+		if err != nil {
+			logf("Closing connection to %s: TLS handshake: %s\n", c.clientAddr, err.Error())
+			return
+		}
+		c.Conn = tlsConn
+		state := tlsConn.ConnectionState()
+		c.sni = state.ServerName
+		c.alpn = state.NegotiatedProtocol
+	}
+
+	err := handler(c)
+	logf("Closing connection to %s: %s\n", c.clientAddr, err.Error())
+}
+
+func loadInstanceMetadata() instanceMetadata {
+	metadata := instanceMetadata{
+		guid: os.Getenv("CF_INSTANCE_GUID"),
+		ip:   os.Getenv("CF_INSTANCE_IP"),
+	}
+	if index, err := strconv.Atoi(os.Getenv("CF_INSTANCE_INDEX")); err == nil {
+		metadata.index = &index
+	}
+	return metadata
+}
+
+func configureTLS() {
+	cert, err := certs.Generate(*serverId, strings.Split(*tlsSAN, ","))
+	if err != nil {
+		fmt.Println("Error generating certificate:", err.Error())
+		os.Exit(1)
+	}
+	tlsFingerprint = fingerprint(cert.Certificate[0])
+	tlsConfig = &tls.Config{
+		// Agree to the client's preferred ALPN protocol so that responses
+		// show what the client offered after passing through the routers.
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			return &tls.Config{
+				Certificates: []tls.Certificate{cert},
+				NextProtos:   hello.SupportedProtos,
+			}, nil
+		},
+	}
+	fmt.Printf("%s:Serving TLS for %s with certificate sha256 %s\n", *serverId, *tlsSAN, tlsFingerprint)
+}
+
+func handshake(conn net.Conn) (*tls.Conn, error) {
+	tlsConn := tls.Server(conn, tlsConfig)
+	err := tlsConn.SetDeadline(time.Now().Add(HEADER_TIMEOUT))
+	if err != nil {
+		return nil, err
+	}
+	err = tlsConn.Handshake()
+	if err != nil {
+		return nil, err
+	}
+	return tlsConn, tlsConn.SetDeadline(time.Time{})
+}
+
+// readProxyHeader consumes the PROXY protocol header, if any, from conn.
+func readProxyHeader(conn net.Conn) (*proxyproto.Conn, error) {
+	err := conn.SetReadDeadline(time.Now().Add(HEADER_TIMEOUT))
+	if err != nil {
+		return nil, err
+	}
+	proxyConn, err := proxyproto.NewConn(conn)
+	if err != nil {
+		return nil, err
+	}
+	return proxyConn, conn.SetReadDeadline(time.Time{})
+}
+
+// logf prints per-connection events unless running in quiet mode.
+func logf(format string, args ...interface{}) {
+	if !*quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
+// logSummary periodically prints connection counts whenever they change.
+func logSummary() {
+	var lastActive, lastAccepted int64 = -1, -1
+	for range time.Tick(SUMMARY_INTERVAL) {
+		active := metrics.total.active.Load()
+		accepted := metrics.total.accepted.Load()
+		if active == lastActive && accepted == lastAccepted {
+			continue
+		}
+		fmt.Printf("%s:Connections active=%d accepted=%d\n", *serverId, active, accepted)
+		lastActive, lastAccepted = active, accepted
+	}
+}
+
+func isTemporary(err error) bool {
+	return errors.Is(err, syscall.EMFILE) ||
+		errors.Is(err, syscall.ENFILE) ||
+		errors.Is(err, syscall.ECONNABORTED)
+}
+
+func nextAcceptDelay(delay time.Duration) time.Duration {
+	if delay == 0 {
+		return 5 * time.Millisecond
+	}
+	delay *= 2
+	if delay > MAX_ACCEPT_DELAY {
+		delay = MAX_ACCEPT_DELAY
+	}
+	return delay
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// counters tracks the traffic on one listener, or on all of them.
+type counters struct {
+	accepted atomic.Int64
+	refused  atomic.Int64
+	active   atomic.Int64
+	messages atomic.Int64
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+}
+
+// counterValues is how counters are reported by GET /metrics.
+type counterValues struct {
+	AcceptedConnections int64 `json:"accepted_connections"`
+	RefusedConnections  int64 `json:"refused_connections"`
+	ActiveConnections   int64 `json:"active_connections"`
+	Messages            int64 `json:"messages"`
+	BytesIn             int64 `json:"bytes_in"`
+	BytesOut            int64 `json:"bytes_out"`
+}
+
+func (c *counters) values() counterValues {
+	return counterValues{
+		AcceptedConnections: c.accepted.Load(),
+		RefusedConnections:  c.refused.Load(),
+		ActiveConnections:   c.active.Load(),
+		Messages:            c.messages.Load(),
+		BytesIn:             c.bytesIn.Load(),
+		BytesOut:            c.bytesOut.Load(),
+	}
+}
+
+// metricsReport is the response to GET /metrics: the totals across all
+// listeners, and the counters of each listener keyed by its address.
+type metricsReport struct {
+	counterValues
+	Listeners map[string]counterValues `json:"listeners"`
+}
+
+type receiverMetrics struct {
+	total counters
+
+	mu        sync.Mutex
+	listeners map[string]*counters
+}
+
+var metrics = &receiverMetrics{
+	listeners: map[string]*counters{},
+}
+
+// listener returns the counters for address, creating them on first use.
+func (m *receiverMetrics) listener(address string) *counters {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.listeners[address]
+	if !ok {
+		c = &counters{}
+		m.listeners[address] = c
+	}
+	return c
+}
+
+func (m *receiverMetrics) report() metricsReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := metricsReport{
+		counterValues: m.total.values(),
+		Listeners:     map[string]counterValues{},
+	}
+	for address, c := range m.listeners {
+		report.Listeners[address] = c.values()
+	}
+	return report
+}
+
+func (m *receiverMetrics) each(address string, update func(c *counters)) {
+	update(&m.total)
+	update(m.listener(address))
+}
+
+// countingConn counts the bytes read and written on an accepted connection,
+// before any PROXY protocol header or TLS is removed.
+type countingConn struct {
+	net.Conn
+	address string
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	metrics.each(c.address, func(c *counters) { c.bytesIn.Add(int64(n)) })
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	metrics.each(c.address, func(c *counters) { c.bytesOut.Add(int64(n)) })
+	return n, err
+}
@@ -0,0 +1,224 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	V1_MAX_LENGTH  = 107
+	V2_HEADER_SIZE = 16
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+	ErrNoHeader = errors.New("no PROXY protocol header")
+)
+
+type Command byte
+
+const (
+	LOCAL Command = 0x0
+	PROXY Command = 0x1
+)
+
+// Header is the connection information carried by a PROXY protocol header.
+// SourceAddr and DestinationAddr are nil for LOCAL and UNKNOWN headers.
+type Header struct {
+	Version         int
+	Command         Command
+	SourceAddr      net.Addr
+	DestinationAddr net.Addr
+}
+
+// ReadHeader consumes a PROXY protocol v1 or v2 header from r. It returns
+// ErrNoHeader without consuming anything if the stream does not start with
+// one, and only blocks for more input while the bytes seen so far could
+// still be the start of a header.
+func ReadHeader(r *bufio.Reader) (*Header, error) {
+	for n := 1; ; n++ {
+		peeked, err := r.Peek(n)
+		if err == io.EOF {
+			// The stream ended before it could be a header; let the caller
+			// read whatever was sent.
+			return nil, ErrNoHeader
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case bytes.Equal(peeked, v1Prefix):
+			return readV1(r)
+		case bytes.Equal(peeked, v2Signature):
+			return readV2(r)
+		case !bytes.HasPrefix(v1Prefix, peeked) && !bytes.HasPrefix(v2Signature, peeked):
+			return nil, ErrNoHeader
+		}
+	}
+}
+
+func readV1(r *bufio.Reader) (*Header, error) {
+	var line []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading v1 header: %w", err)
+		}
+		line = append(line, b)
+		if len(line) > V1_MAX_LENGTH {
+			return nil, errors.New("v1 header exceeds maximum length")
+		}
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			break
+		}
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	header := &Header{Version: 1, Command: PROXY}
+
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return header, nil
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("malformed v1 header %q", line)
+	}
+
+	var ipLen int
+	switch fields[1] {
+	case "TCP4":
+		ipLen = net.IPv4len
+	case "TCP6":
+		ipLen = net.IPv6len
+	default:
+		return nil, fmt.Errorf("unsupported v1 protocol %q", fields[1])
+	}
+
+	src, err := parseV1Addr(fields[2], fields[4], ipLen)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := parseV1Addr(fields[3], fields[5], ipLen)
+	if err != nil {
+		return nil, err
+	}
+
+	header.SourceAddr = src
+	header.DestinationAddr = dst
+	return header, nil
+}
+
+func parseV1Addr(host, port string, ipLen int) (*net.TCPAddr, error) {
+	ip := net.ParseIP(host)
+	isIPv4 := ip != nil && !strings.Contains(host, ":")
+	if ip == nil || isIPv4 != (ipLen == net.IPv4len) {
+		return nil, fmt.Errorf("invalid v1 address %q", host)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || (len(port) > 1 && port[0] == '0') {
+		return nil, fmt.Errorf("invalid v1 port %q", port)
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(p)}, nil
+}
+
+func readV2(r *bufio.Reader) (*Header, error) {
+	fixed := make([]byte, V2_HEADER_SIZE)
+	_, err := io.ReadFull(r, fixed)
+	if err != nil {
+		return nil, fmt.Errorf("reading v2 header: %w", err)
+	}
+
+	if fixed[12]>>4 != 0x2 {
+		return nil, fmt.Errorf("unsupported v2 version %d", fixed[12]>>4)
+	}
+
+	header := &Header{Version: 2, Command: Command(fixed[12] & 0x0F)}
+	if header.Command != LOCAL && header.Command != PROXY {
+		return nil, fmt.Errorf("unsupported v2 command %d", header.Command)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(fixed[14:16]))
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return nil, fmt.Errorf("reading v2 addresses: %w", err)
+	}
+
+	// LOCAL connections (e.g. health checks) carry no addresses worth using.
+	if header.Command == LOCAL {
+		return header, nil
+	}
+
+	family, transport := fixed[13]>>4, fixed[13]&0x0F
+	var ipLen int
+	switch family {
+	case 0x1:
+		ipLen = net.IPv4len
+	case 0x2:
+		ipLen = net.IPv6len
+	default:
+		// AF_UNSPEC and AF_UNIX addresses are skipped, as allowed by the spec.
+		return header, nil
+	}
+
+	if len(payload) < 2*ipLen+4 {
+		return nil, fmt.Errorf("v2 address block too short: %d bytes", len(payload))
+	}
+
+	srcIP := net.IP(payload[:ipLen])
+	dstIP := net.IP(payload[ipLen : 2*ipLen])
+	srcPort := int(binary.BigEndian.Uint16(payload[2*ipLen:]))
+	dstPort := int(binary.BigEndian.Uint16(payload[2*ipLen+2:]))
+
+	if transport == 0x2 {
+		header.SourceAddr = &net.UDPAddr{IP: srcIP, Port: srcPort}
+		header.DestinationAddr = &net.UDPAddr{IP: dstIP, Port: dstPort}
+	} else {
+		header.SourceAddr = &net.TCPAddr{IP: srcIP, Port: srcPort}
+		header.DestinationAddr = &net.TCPAddr{IP: dstIP, Port: dstPort}
+	}
+
+	return header, nil
+}
+
+// Conn is a net.Conn whose PROXY protocol header, if any, has been consumed.
+type Conn struct {
+	net.Conn
+	reader *bufio.Reader
+	Header *Header
+}
+
+// NewConn reads an optional PROXY protocol header from conn. Connections
+// without a header are passed through unchanged.
+func NewConn(conn net.Conn) (*Conn, error) {
+	reader := bufio.NewReader(conn)
+	header, err := ReadHeader(reader)
+	if err != nil && err != ErrNoHeader {
+		return nil, err
+	}
+
+	return &Conn{Conn: conn, reader: reader, Header: header}, nil
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// ClientAddr returns the source address from the PROXY protocol header, or
+// the remote address of the connection when the header did not carry one.
+func (c *Conn) ClientAddr() net.Addr {
+	if c.Header != nil && c.Header.SourceAddr != nil {
+		return c.Header.SourceAddr
+	}
+	return c.Conn.RemoteAddr()
+}
@@ -0,0 +1,13 @@
+package proxyproto_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestProxyproto(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Proxyproto Suite")
+}
@@ -0,0 +1,292 @@
+package proxyproto_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/routing-acceptance-tests/assets/tcp-receiver/proxyproto"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+func v2Header(verCmd, famProto byte, payload []byte) []byte {
+	header := append([]byte{}, v2Signature...)
+	header = append(header, verCmd, famProto)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	return append(header, payload...)
+}
+
+func v2Addresses(src, dst net.IP, srcPort, dstPort uint16) []byte {
+	payload := append([]byte{}, src...)
+	payload = append(payload, dst...)
+	payload = binary.BigEndian.AppendUint16(payload, srcPort)
+	return binary.BigEndian.AppendUint16(payload, dstPort)
+}
+
+var _ = Describe("ReadHeader", func() {
+	var (
+		input  []byte
+		reader *bufio.Reader
+	)
+
+	JustBeforeEach(func() {
+		reader = bufio.NewReader(bytes.NewReader(input))
+	})
+
+	remaining := func() string {
+		rest, err := io.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		return string(rest)
+	}
+
+	Context("with a v1 header", func() {
+		Context("for TCP4", func() {
+			BeforeEach(func() {
+				input = []byte("PROXY TCP4 203.0.113.7 10.0.0.1 56324 3333\r\nhello")
+			})
+
+			It("returns the source and destination addresses", func() {
+				header, err := proxyproto.ReadHeader(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.Version).To(Equal(1))
+				Expect(header.Command).To(Equal(proxyproto.PROXY))
+				Expect(header.SourceAddr.String()).To(Equal("203.0.113.7:56324"))
+				Expect(header.DestinationAddr.String()).To(Equal("10.0.0.1:3333"))
+				Expect(remaining()).To(Equal("hello"))
+			})
+		})
+
+		Context("for TCP6", func() {
+			BeforeEach(func() {
+				input = []byte("PROXY TCP6 2001:db8::7 2001:db8::1 56324 3333\r\nhello")
+			})
+
+			It("returns the source and destination addresses", func() {
+				header, err := proxyproto.ReadHeader(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.SourceAddr.String()).To(Equal("[2001:db8::7]:56324"))
+				Expect(header.DestinationAddr.String()).To(Equal("[2001:db8::1]:3333"))
+				Expect(remaining()).To(Equal("hello"))
+			})
+		})
+
+		Context("for an UNKNOWN connection", func() {
+			BeforeEach(func() {
+				input = []byte("PROXY UNKNOWN ffff::1 ffff::2 1 2\r\nhello")
+			})
+
+			It("returns a header without addresses", func() {
+				header, err := proxyproto.ReadHeader(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.Version).To(Equal(1))
+				Expect(header.SourceAddr).To(BeNil())
+				Expect(remaining()).To(Equal("hello"))
+			})
+		})
+
+		DescribeTable("rejects malformed headers",
+			func(line string) {
+				reader = bufio.NewReader(strings.NewReader(line))
+				_, err := proxyproto.ReadHeader(reader)
+				Expect(err).To(HaveOccurred())
+				Expect(err).NotTo(Equal(proxyproto.ErrNoHeader))
+			},
+			Entry("missing fields", "PROXY TCP4 203.0.113.7 10.0.0.1 56324\r\n"),
+			Entry("unknown protocol", "PROXY UDP4 203.0.113.7 10.0.0.1 56324 3333\r\n"),
+			Entry("IPv6 address for TCP4", "PROXY TCP4 2001:db8::7 10.0.0.1 56324 3333\r\n"),
+			Entry("IPv4 address for TCP6", "PROXY TCP6 203.0.113.7 2001:db8::1 56324 3333\r\n"),
+			Entry("invalid address", "PROXY TCP4 203.0.113.300 10.0.0.1 56324 3333\r\n"),
+			Entry("out of range port", "PROXY TCP4 203.0.113.7 10.0.0.1 65536 3333\r\n"),
+			Entry("port with leading zero", "PROXY TCP4 203.0.113.7 10.0.0.1 056324 3333\r\n"),
+			Entry("missing CRLF", "PROXY TCP4 203.0.113.7 10.0.0.1 56324 3333"),
+			Entry("overlong line", "PROXY TCP4 "+strings.Repeat("1", 120)+"\r\n"),
+		)
+	})
+
+	Context("with a v2 header", func() {
+		Context("for TCP over IPv4", func() {
+			BeforeEach(func() {
+				addrs := v2Addresses(net.IPv4(203, 0, 113, 7).To4(), net.IPv4(10, 0, 0, 1).To4(), 56324, 3333)
+				input = append(v2Header(0x21, 0x11, addrs), []byte("hello")...)
+			})
+
+			It("returns the source and destination addresses", func() {
+				header, err := proxyproto.ReadHeader(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.Version).To(Equal(2))
+				Expect(header.Command).To(Equal(proxyproto.PROXY))
+				Expect(header.SourceAddr).To(BeAssignableToTypeOf(&net.TCPAddr{}))
+				Expect(header.SourceAddr.String()).To(Equal("203.0.113.7:56324"))
+				Expect(header.DestinationAddr.String()).To(Equal("10.0.0.1:3333"))
+				Expect(remaining()).To(Equal("hello"))
+			})
+		})
+
+		Context("for TCP over IPv6 with TLVs", func() {
+			BeforeEach(func() {
+				addrs := v2Addresses(net.ParseIP("2001:db8::7"), net.ParseIP("2001:db8::1"), 56324, 3333)
+				tlv := []byte{0x04, 0x00, 0x03, 'a', 'b', 'c'}
+				input = append(v2Header(0x21, 0x21, append(addrs, tlv...)), []byte("hello")...)
+			})
+
+			It("returns the addresses and skips the TLVs", func() {
+				header, err := proxyproto.ReadHeader(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.SourceAddr.String()).To(Equal("[2001:db8::7]:56324"))
+				Expect(header.DestinationAddr.String()).To(Equal("[2001:db8::1]:3333"))
+				Expect(remaining()).To(Equal("hello"))
+			})
+		})
+
+		Context("for UDP over IPv4", func() {
+			BeforeEach(func() {
+				addrs := v2Addresses(net.IPv4(203, 0, 113, 7).To4(), net.IPv4(10, 0, 0, 1).To4(), 56324, 3333)
+				input = v2Header(0x21, 0x12, addrs)
+			})
+
+			It("returns UDP addresses", func() {
+				header, err := proxyproto.ReadHeader(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.SourceAddr).To(BeAssignableToTypeOf(&net.UDPAddr{}))
+			})
+		})
+
+		Context("for a LOCAL connection", func() {
+			BeforeEach(func() {
+				addrs := v2Addresses(net.IPv4(203, 0, 113, 7).To4(), net.IPv4(10, 0, 0, 1).To4(), 56324, 3333)
+				input = append(v2Header(0x20, 0x11, addrs), []byte("hello")...)
+			})
+
+			It("returns a header without addresses", func() {
+				header, err := proxyproto.ReadHeader(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.Command).To(Equal(proxyproto.LOCAL))
+				Expect(header.SourceAddr).To(BeNil())
+				Expect(remaining()).To(Equal("hello"))
+			})
+		})
+
+		Context("for an unspecified address family", func() {
+			BeforeEach(func() {
+				input = append(v2Header(0x21, 0x00, nil), []byte("hello")...)
+			})
+
+			It("returns a header without addresses", func() {
+				header, err := proxyproto.ReadHeader(reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(header.SourceAddr).To(BeNil())
+				Expect(remaining()).To(Equal("hello"))
+			})
+		})
+
+		DescribeTable("rejects malformed headers",
+			func(header []byte) {
+				reader = bufio.NewReader(bytes.NewReader(header))
+				_, err := proxyproto.ReadHeader(reader)
+				Expect(err).To(HaveOccurred())
+				Expect(err).NotTo(Equal(proxyproto.ErrNoHeader))
+			},
+			Entry("unsupported version", v2Header(0x11, 0x11, make([]byte, 12))),
+			Entry("unsupported command", v2Header(0x22, 0x11, make([]byte, 12))),
+			Entry("address block too short", v2Header(0x21, 0x21, make([]byte, 12))),
+			Entry("truncated payload", v2Header(0x21, 0x11, make([]byte, 12))[:20]),
+			Entry("truncated fixed header", v2Header(0x21, 0x11, nil)[:14]),
+		)
+	})
+
+	Context("without a header", func() {
+		BeforeEach(func() {
+			input = []byte("PROXIMITY alert")
+		})
+
+		It("returns ErrNoHeader and consumes nothing", func() {
+			_, err := proxyproto.ReadHeader(reader)
+			Expect(err).To(Equal(proxyproto.ErrNoHeader))
+			Expect(remaining()).To(Equal("PROXIMITY alert"))
+		})
+	})
+
+	Context("with a message shorter than a header", func() {
+		It("does not wait for more input", func() {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			go func() {
+				defer GinkgoRecover()
+				_, err := client.Write([]byte("hi"))
+				Expect(err).NotTo(HaveOccurred())
+			}()
+
+			reader := bufio.NewReader(server)
+			Expect(server.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+			_, err := proxyproto.ReadHeader(reader)
+			Expect(err).To(Equal(proxyproto.ErrNoHeader))
+		})
+	})
+})
+
+var _ = Describe("Conn", func() {
+	var client, server net.Conn
+
+	BeforeEach(func() {
+		client, server = net.Pipe()
+	})
+
+	AfterEach(func() {
+		client.Close()
+		server.Close()
+	})
+
+	It("reports the header source address and reads the payload after it", func() {
+		go func() {
+			defer GinkgoRecover()
+			_, err := client.Write([]byte("PROXY TCP4 203.0.113.7 10.0.0.1 56324 3333\r\nhello"))
+			Expect(err).NotTo(HaveOccurred())
+		}()
+
+		conn, err := proxyproto.NewConn(server)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.ClientAddr().String()).To(Equal("203.0.113.7:56324"))
+
+		buff := make([]byte, 5)
+		_, err = io.ReadFull(conn, buff)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(buff)).To(Equal("hello"))
+	})
+
+	It("falls back to the remote address without a header", func() {
+		go func() {
+			defer GinkgoRecover()
+			_, err := client.Write([]byte("hello"))
+			Expect(err).NotTo(HaveOccurred())
+		}()
+
+		conn, err := proxyproto.NewConn(server)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.Header).To(BeNil())
+		Expect(conn.ClientAddr()).To(Equal(server.RemoteAddr()))
+
+		buff := make([]byte, 5)
+		_, err = io.ReadFull(conn, buff)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(buff)).To(Equal("hello"))
+	})
+
+	It("fails on a malformed header", func() {
+		go func() {
+			_, _ = client.Write([]byte("PROXY TCP4 nonsense\r\n"))
+		}()
+
+		_, err := proxyproto.NewConn(server)
+		Expect(err).To(HaveOccurred())
+	})
+})
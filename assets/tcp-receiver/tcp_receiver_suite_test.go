@@ -0,0 +1,62 @@
+package main_test
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var receiverBinPath string
+
+func TestTcpReceiver(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TCP Receiver Suite")
+}
+
+var _ = SynchronizedBeforeSuite(func() []byte {
+	path, err := gexec.Build("github.com/cloudfoundry/routing-acceptance-tests/assets/tcp-receiver")
+	Expect(err).NotTo(HaveOccurred())
+	return []byte(path)
+}, func(path []byte) {
+	receiverBinPath = string(path)
+})
+
+var _ = SynchronizedAfterSuite(func() {}, func() {
+	gexec.CleanupBuildArtifacts()
+})
+
+func freePort() uint16 {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	defer listener.Close()
+	return uint16(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func localAddress(port uint16) string {
+	return fmt.Sprintf("127.0.0.1:%d", port)
+}
+
+func dial(address string) net.Conn {
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	Expect(err).NotTo(HaveOccurred())
+	return conn
+}
+
+func read(conn net.Conn) string {
+	Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+	buff := make([]byte, 1024)
+	n, err := conn.Read(buff)
+	Expect(err).NotTo(HaveOccurred())
+	return string(buff[:n])
+}
+
+func exchange(conn net.Conn, message string) string {
+	_, err := conn.Write([]byte(message))
+	Expect(err).NotTo(HaveOccurred())
+	return read(conn)
+}
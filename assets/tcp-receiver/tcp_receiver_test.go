@@ -0,0 +1,735 @@
+package main_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry/routing-acceptance-tests/assets/tcp-receiver/testrunner"
+	"github.com/tedsuo/ifrit"
+	ginkgomon "github.com/tedsuo/ifrit/ginkgomon_v2"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("TCP Receiver", func() {
+	var (
+		args    testrunner.Args
+		runner  *ginkgomon.Runner
+		process ifrit.Process
+		port    uint16
+		address string
+	)
+
+	BeforeEach(func() {
+		port = freePort()
+		address = localAddress(port)
+		args = testrunner.Args{
+			Address:  address,
+			ServerId: "server1",
+		}
+	})
+
+	JustBeforeEach(func() {
+		runner = testrunner.New(receiverBinPath, args)
+		process = ginkgomon.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		ginkgomon.Interrupt(process, 5*time.Second)
+	})
+
+	Context("in the default identify mode", func() {
+		It("prefixes every message with the server id", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			Expect(exchange(conn, "hello")).To(Equal("server1:hello"))
+			Expect(exchange(conn, "again")).To(Equal("server1:again"))
+		})
+
+		It("logs each message", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			exchange(conn, "hello")
+			Eventually(runner.Buffer()).Should(gbytes.Say("Message to .*: server1:hello"))
+		})
+
+		Context("with multiple addresses", func() {
+			var secondAddress string
+
+			BeforeEach(func() {
+				secondAddress = localAddress(freePort())
+				args.Address = address + "," + secondAddress
+			})
+
+			It("includes the address that received the message", func() {
+				conn := dial(address)
+				defer conn.Close()
+				Expect(exchange(conn, "hello")).To(Equal("server1(" + address + "):hello"))
+
+				secondConn := dial(secondAddress)
+				defer secondConn.Close()
+				Expect(exchange(secondConn, "hello")).To(Equal("server1(" + secondAddress + "):hello"))
+			})
+		})
+
+		Context("when reporting the client address", func() {
+			BeforeEach(func() {
+				args.ReportClientAddress = true
+			})
+
+			It("appends the remote address of the connection", func() {
+				conn := dial(address)
+				defer conn.Close()
+
+				Expect(exchange(conn, "hello")).To(Equal("server1:hello client_address=" + conn.LocalAddr().String()))
+			})
+
+			Context("with the PROXY protocol", func() {
+				BeforeEach(func() {
+					args.ProxyProtocol = true
+				})
+
+				It("appends the source address from the header", func() {
+					conn := dial(address)
+					defer conn.Close()
+
+					Expect(exchange(conn, "PROXY TCP4 203.0.113.7 10.0.0.1 56324 3333\r\nhello")).To(Equal("server1:hello client_address=203.0.113.7:56324"))
+				})
+
+				It("falls back to the remote address without a header", func() {
+					conn := dial(address)
+					defer conn.Close()
+
+					Expect(exchange(conn, "hello")).To(Equal("server1:hello client_address=" + conn.LocalAddr().String()))
+				})
+			})
+		})
+
+		Context("in quiet mode", func() {
+			BeforeEach(func() {
+				args.Quiet = true
+			})
+
+			It("does not log each message", func() {
+				conn := dial(address)
+				defer conn.Close()
+
+				Expect(exchange(conn, "hello")).To(Equal("server1:hello"))
+				Consistently(runner.Buffer(), 500*time.Millisecond).ShouldNot(gbytes.Say("Message to"))
+			})
+		})
+	})
+
+	Context("with the json format", func() {
+		type identity struct {
+			ServerID      string `json:"server_id"`
+			ListenAddress string `json:"listen_address"`
+			InstanceIndex *int   `json:"instance_index"`
+			InstanceGUID  string `json:"instance_guid"`
+			InstanceIP    string `json:"instance_ip"`
+			ClientAddress string `json:"client_address"`
+			Bytes         int    `json:"bytes"`
+			Message       string `json:"message"`
+			SNI           string `json:"sni"`
+		}
+
+		decode := func(response string) identity {
+			var id identity
+			Expect(json.Unmarshal([]byte(response), &id)).To(Succeed())
+			return id
+		}
+
+		BeforeEach(func() {
+			args.Format = "json"
+			args.ServerId = "server:1"
+			args.Env = []string{
+				"CF_INSTANCE_INDEX=3",
+				"CF_INSTANCE_GUID=6f3e2a1c-0d4b-4a8e-9b7c-2f1e0a9d8c7b",
+				"CF_INSTANCE_IP=10.0.16.5",
+			}
+		})
+
+		It("describes the server, the instance and the client", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			id := decode(exchange(conn, "hello: world"))
+			Expect(id.ServerID).To(Equal("server:1"))
+			Expect(id.ListenAddress).To(Equal(address))
+			Expect(id.InstanceIndex).To(HaveValue(Equal(3)))
+			Expect(id.InstanceGUID).To(Equal("6f3e2a1c-0d4b-4a8e-9b7c-2f1e0a9d8c7b"))
+			Expect(id.InstanceIP).To(Equal("10.0.16.5"))
+			Expect(id.ClientAddress).To(Equal(conn.LocalAddr().String()))
+			Expect(id.Bytes).To(Equal(len("hello: world")))
+			Expect(id.Message).To(Equal("hello: world"))
+			Expect(id.SNI).To(BeEmpty())
+		})
+
+		Context("outside of Cloud Foundry", func() {
+			BeforeEach(func() {
+				args.Env = []string{"CF_INSTANCE_INDEX=", "CF_INSTANCE_GUID=", "CF_INSTANCE_IP="}
+			})
+
+			It("leaves the instance fields empty", func() {
+				conn := dial(address)
+				defer conn.Close()
+
+				id := decode(exchange(conn, "hello"))
+				Expect(id.InstanceIndex).To(BeNil())
+				Expect(id.InstanceGUID).To(BeEmpty())
+				Expect(id.InstanceIP).To(BeEmpty())
+			})
+		})
+
+		Context("with TLS", func() {
+			BeforeEach(func() {
+				args.TLS = true
+				args.TLSSAN = "app.example.com"
+			})
+
+			It("includes the SNI", func() {
+				conn, err := tls.Dial("tcp", address, &tls.Config{
+					ServerName:         "app.example.com",
+					InsecureSkipVerify: true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				defer conn.Close()
+
+				Expect(decode(exchange(conn, "hello")).SNI).To(Equal("app.example.com"))
+			})
+		})
+	})
+
+	Context("when $PORT is set", func() {
+		var (
+			adminURL string
+			client   *http.Client
+		)
+
+		const adminToken = "admin-token"
+
+		BeforeEach(func() {
+			adminPort := freePort()
+			args.Port = adminPort
+			args.Env = append(args.Env, "RECEIVER_ADMIN_TOKEN="+adminToken)
+			adminURL = "http://" + localAddress(adminPort)
+			client = &http.Client{Timeout: 5 * time.Second}
+		})
+
+		send := func(method, path, token string) *http.Response {
+			req, err := http.NewRequest(method, adminURL+path, nil)
+			Expect(err).NotTo(HaveOccurred())
+			if token != "" {
+				req.Header.Set("X-Receiver-Admin-Token", token)
+			}
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(resp.Body.Close)
+			return resp
+		}
+
+		do := func(method, path string) *http.Response {
+			return send(method, path, adminToken)
+		}
+
+		type adminState struct {
+			Ready             bool     `json:"ready"`
+			Refusing          bool     `json:"refusing"`
+			Latency           string   `json:"latency"`
+			Listeners         []string `json:"listeners"`
+			ActiveConnections int      `json:"active_connections"`
+		}
+
+		getState := func() adminState {
+			resp := do("GET", "/state")
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			var s adminState
+			Expect(json.NewDecoder(resp.Body).Decode(&s)).To(Succeed())
+			return s
+		}
+
+		It("serves the admin API on $PORT", func() {
+			Expect(getState()).To(Equal(adminState{
+				Ready:     true,
+				Latency:   "0s",
+				Listeners: []string{address},
+			}))
+		})
+
+		It("rejects requests without the admin token", func() {
+			Expect(send("PUT", "/latency?latency=500ms", "").StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(send("POST", "/crash", "wrong-token").StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(send("GET", "/state", "").StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(getState().Latency).To(Equal("0s"))
+		})
+
+		It("serves GET /ready without the admin token, for health checks", func() {
+			Expect(send("GET", "/ready", "").StatusCode).To(Equal(http.StatusOK))
+			Expect(send("PUT", "/ready?ready=false", "").StatusCode).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("toggles readiness", func() {
+			Expect(do("GET", "/ready").StatusCode).To(Equal(http.StatusOK))
+
+			Expect(do("PUT", "/ready?ready=false").StatusCode).To(Equal(http.StatusOK))
+			Expect(do("GET", "/ready").StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+			Expect(do("PUT", "/ready?ready=true").StatusCode).To(Equal(http.StatusOK))
+			Expect(do("GET", "/ready").StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("refuses new connections until told to accept them again", func() {
+			Expect(do("PUT", "/refuse?refuse=true").StatusCode).To(Equal(http.StatusOK))
+
+			// The reset can arrive before or after the dial returns.
+			conn, err := net.DialTimeout("tcp", address, time.Second)
+			if err == nil {
+				defer conn.Close()
+				_, _ = conn.Write([]byte("hello"))
+				Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+				_, err = conn.Read(make([]byte, 1))
+			}
+			Expect(err).To(HaveOccurred())
+
+			Expect(do("PUT", "/refuse?refuse=false").StatusCode).To(Equal(http.StatusOK))
+			accepted := dial(address)
+			defer accepted.Close()
+			Expect(exchange(accepted, "hello")).To(Equal("server1:hello"))
+		})
+
+		It("adds latency to every response", func() {
+			Expect(do("PUT", "/latency?latency=500ms").StatusCode).To(Equal(http.StatusOK))
+			Expect(getState().Latency).To(Equal("500ms"))
+
+			conn := dial(address)
+			defer conn.Close()
+			start := time.Now()
+			Expect(exchange(conn, "hello")).To(Equal("server1:hello"))
+			Expect(time.Since(start)).To(BeNumerically(">=", 500*time.Millisecond))
+		})
+
+		Context("with an initial latency", func() {
+			BeforeEach(func() {
+				args.Latency = 300 * time.Millisecond
+			})
+
+			It("reports it and can remove it", func() {
+				Expect(getState().Latency).To(Equal("300ms"))
+				Expect(do("PUT", "/latency?latency=0s").StatusCode).To(Equal(http.StatusOK))
+				Expect(getState().Latency).To(Equal("0s"))
+			})
+		})
+
+		It("rejects an invalid latency", func() {
+			Expect(do("PUT", "/latency?latency=soon").StatusCode).To(Equal(http.StatusBadRequest))
+		})
+
+		It("resets active connections", func() {
+			conn := dial(address)
+			defer conn.Close()
+			Expect(exchange(conn, "hello")).To(Equal("server1:hello"))
+			Eventually(getState).Should(HaveField("ActiveConnections", 1))
+
+			resp := do("DELETE", "/connections")
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(MatchJSON(`{"reset":1}`))
+
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			_, err = conn.Read(make([]byte, 1))
+			Expect(err).To(HaveOccurred())
+			Eventually(getState).Should(HaveField("ActiveConnections", 0))
+		})
+
+		It("closes a listener", func() {
+			Expect(do("DELETE", "/listeners?address="+address).StatusCode).To(Equal(http.StatusNoContent))
+
+			Eventually(func() error {
+				conn, err := net.DialTimeout("tcp", address, time.Second)
+				if err == nil {
+					conn.Close()
+				}
+				return err
+			}).Should(HaveOccurred())
+			Eventually(getState).Should(HaveField("Listeners", BeEmpty()))
+
+			Expect(do("DELETE", "/listeners?address="+address).StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		Context("with multiple addresses", func() {
+			var secondAddress string
+
+			type counters struct {
+				AcceptedConnections int64 `json:"accepted_connections"`
+				RefusedConnections  int64 `json:"refused_connections"`
+				ActiveConnections   int64 `json:"active_connections"`
+				Messages            int64 `json:"messages"`
+				BytesIn             int64 `json:"bytes_in"`
+				BytesOut            int64 `json:"bytes_out"`
+			}
+
+			type metrics struct {
+				counters
+				Listeners map[string]counters `json:"listeners"`
+			}
+
+			getMetrics := func() metrics {
+				resp := do("GET", "/metrics")
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				var m metrics
+				Expect(json.NewDecoder(resp.Body).Decode(&m)).To(Succeed())
+				return m
+			}
+
+			BeforeEach(func() {
+				secondAddress = localAddress(freePort())
+				args.Address = address + "," + secondAddress
+			})
+
+			It("reports traffic in total and per listener", func() {
+				Expect(getMetrics()).To(Equal(metrics{
+					Listeners: map[string]counters{address: {}, secondAddress: {}},
+				}))
+
+				conn := dial(secondAddress)
+				defer conn.Close()
+				response := exchange(conn, "hello")
+				Expect(exchange(conn, "again")).To(HaveLen(len(response)))
+
+				expected := counters{
+					AcceptedConnections: 1,
+					ActiveConnections:   1,
+					Messages:            2,
+					BytesIn:             int64(len("hello") + len("again")),
+					BytesOut:            int64(2 * len(response)),
+				}
+				Eventually(getMetrics).Should(Equal(metrics{
+					counters:  expected,
+					Listeners: map[string]counters{address: {}, secondAddress: expected},
+				}))
+
+				conn.Close()
+				Eventually(func() int64 { return getMetrics().Listeners[secondAddress].ActiveConnections }).Should(BeZero())
+			})
+
+			It("counts refused connections", func() {
+				Expect(do("PUT", "/refuse?refuse=true").StatusCode).To(Equal(http.StatusOK))
+
+				conn, err := net.DialTimeout("tcp", address, time.Second)
+				if err == nil {
+					conn.Close()
+				}
+				Eventually(func() int64 { return getMetrics().Listeners[address].RefusedConnections }).Should(Equal(int64(1)))
+				Expect(getMetrics().AcceptedConnections).To(BeZero())
+			})
+		})
+
+		It("crashes the process", func() {
+			Expect(do("POST", "/crash?exit_code=3").StatusCode).To(Equal(http.StatusAccepted))
+
+			var err error
+			Eventually(process.Wait(), 5*time.Second).Should(Receive(&err))
+			Expect(err).To(MatchError(ContainSubstring("exit status 3")))
+		})
+	})
+
+	Context("in echo mode", func() {
+		BeforeEach(func() {
+			args.Mode = "echo"
+		})
+
+		It("writes every message back unchanged", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			Expect(exchange(conn, "hello")).To(Equal("hello"))
+		})
+	})
+
+	Context("in delay mode", func() {
+		BeforeEach(func() {
+			args.Mode = "delay"
+			args.Delay = 500 * time.Millisecond
+		})
+
+		It("identifies every message after the delay", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			start := time.Now()
+			Expect(exchange(conn, "hello")).To(Equal("server1:hello"))
+			Expect(time.Since(start)).To(BeNumerically(">=", 500*time.Millisecond))
+		})
+	})
+
+	Context("in close-after mode", func() {
+		BeforeEach(func() {
+			args.Mode = "close-after"
+			args.CloseAfter = 2
+		})
+
+		It("closes the connection after responding to the configured number of messages", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			Expect(exchange(conn, "one")).To(Equal("server1:one"))
+			Expect(exchange(conn, "two")).To(Equal("server1:two"))
+
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			_, err := conn.Read(make([]byte, 1))
+			Expect(err).To(Equal(io.EOF))
+		})
+	})
+
+	Context("in greeting mode", func() {
+		BeforeEach(func() {
+			args.Mode = "greeting"
+		})
+
+		It("sends the default banner before the client writes anything", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			Expect(read(conn)).To(Equal("220 server1 ready\r\n"))
+			Expect(exchange(conn, "hello")).To(Equal("server1:hello"))
+		})
+
+		Context("with a custom greeting", func() {
+			BeforeEach(func() {
+				args.Greeting = "+OK POP3 ready"
+			})
+
+			It("sends it on accept", func() {
+				conn := dial(address)
+				defer conn.Close()
+
+				Expect(read(conn)).To(Equal("+OK POP3 ready\r\n"))
+			})
+		})
+	})
+
+	Context("in stream mode", func() {
+		BeforeEach(func() {
+			args.Mode = "stream"
+		})
+
+		It("echoes a large stream byte for byte", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			payload := make([]byte, 4*1024*1024)
+			_, err := rand.Read(payload)
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				defer GinkgoRecover()
+				_, err := conn.Write(payload)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conn.(*net.TCPConn).CloseWrite()).To(Succeed())
+			}()
+
+			Expect(conn.SetReadDeadline(time.Now().Add(10 * time.Second))).To(Succeed())
+			received, err := io.ReadAll(conn)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bytes.Equal(received, payload)).To(BeTrue(), "received %d bytes that differ from the %d sent", len(received), len(payload))
+		})
+	})
+
+	Context("with TLS", func() {
+		BeforeEach(func() {
+			args.TLS = true
+			args.TLSSAN = "app.example.com,127.0.0.1"
+		})
+
+		It("reports the SNI, negotiated ALPN protocol and certificate fingerprint", func() {
+			conn, err := tls.Dial("tcp", address, &tls.Config{
+				ServerName:         "app.example.com",
+				NextProtos:         []string{"rats-test/1", "h2"},
+				InsecureSkipVerify: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			state := conn.ConnectionState()
+			Expect(state.NegotiatedProtocol).To(Equal("rats-test/1"))
+			leaf := state.PeerCertificates[0]
+			Expect(leaf.VerifyHostname("app.example.com")).To(Succeed())
+			Expect(leaf.VerifyHostname("127.0.0.1")).To(Succeed())
+
+			sum := sha256.Sum256(leaf.Raw)
+			Expect(exchange(conn, "hello")).To(Equal("server1:hello sni=app.example.com alpn=rats-test/1 cert_sha256=" + hex.EncodeToString(sum[:])))
+		})
+
+		It("rejects plaintext clients", func() {
+			conn := dial(address)
+			defer conn.Close()
+
+			_, err := conn.Write([]byte("hello\r\n\r\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			response, _ := io.ReadAll(conn)
+			Expect(string(response)).NotTo(ContainSubstring("server1:hello"))
+		})
+	})
+
+	Context("when stopped", func() {
+		var conn net.Conn
+
+		stop := func() {
+			process.Signal(syscall.SIGTERM)
+		}
+
+		exited := func() {
+			var err error
+			Eventually(process.Wait(), 10*time.Second).Should(Receive(&err))
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			args.Mode = "delay"
+			args.Delay = time.Second
+			args.DrainTimeout = 5 * time.Second
+		})
+
+		JustBeforeEach(func() {
+			conn = dial(address)
+		})
+
+		AfterEach(func() {
+			conn.Close()
+		})
+
+		It("exits once there is nothing to drain", func() {
+			conn.Close()
+			Eventually(runner.Buffer).Should(gbytes.Say("Closing connection"))
+
+			stop()
+			exited()
+			Expect(runner.Buffer()).To(gbytes.Say("Received terminated, draining for up to 5s"))
+			Expect(runner.Buffer()).To(gbytes.Say("Drained in .*: completed_exchanges=0 idle_closed=0 forcibly_closed=0"))
+		})
+
+		It("finishes the exchange in flight, then closes the connection", func() {
+			_, err := conn.Write([]byte("in flight"))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(runner.Buffer).Should(gbytes.Say("Remote Address"))
+			time.Sleep(200 * time.Millisecond)
+
+			stop()
+			Expect(read(conn)).To(Equal("server1:in flight"))
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			_, err = conn.Read(make([]byte, 1))
+			Expect(err).To(Equal(io.EOF))
+
+			exited()
+			Expect(runner.Buffer()).To(gbytes.Say("completed_exchanges=1 idle_closed=0 forcibly_closed=0"))
+		})
+
+		It("stops accepting new connections", func() {
+			_, err := conn.Write([]byte("in flight"))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(runner.Buffer).Should(gbytes.Say("Remote Address"))
+			time.Sleep(200 * time.Millisecond)
+
+			stop()
+			Eventually(runner.Buffer).Should(gbytes.Say("draining"))
+			_, err = net.DialTimeout("tcp", address, time.Second)
+			Expect(err).To(HaveOccurred())
+
+			Expect(read(conn)).To(Equal("server1:in flight"))
+			exited()
+		})
+
+		It("closes idle connections straight away", func() {
+			Expect(exchange(conn, "first")).To(Equal("server1:first"))
+
+			start := time.Now()
+			stop()
+			Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+			_, err := conn.Read(make([]byte, 1))
+			Expect(err).To(Equal(io.EOF))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+			exited()
+			Expect(runner.Buffer()).To(gbytes.Say("completed_exchanges=0 idle_closed=1 forcibly_closed=0"))
+		})
+
+		Context("when an exchange outlasts the drain timeout", func() {
+			BeforeEach(func() {
+				args.Delay = 3 * time.Second
+				args.DrainTimeout = 500 * time.Millisecond
+			})
+
+			It("closes it at the deadline", func() {
+				_, err := conn.Write([]byte("too slow"))
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(runner.Buffer).Should(gbytes.Say("Remote Address"))
+				time.Sleep(100 * time.Millisecond)
+
+				stop()
+				exited()
+				Expect(runner.Buffer()).To(gbytes.Say("completed_exchanges=0 idle_closed=0 forcibly_closed=1"))
+			})
+		})
+	})
+
+	Context("with an address on $PORT", func() {
+		It("exits with an error", func() {
+			// Replaces the process started by JustBeforeEach.
+			ginkgomon.Interrupt(process, 5*time.Second)
+
+			port := freePort()
+			cmd := exec.Command(receiverBinPath, "-address="+localAddress(port))
+			cmd.Env = append(os.Environ(), fmt.Sprintf("PORT=%d", port))
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Out).To(gbytes.Say(fmt.Sprintf(`Cannot listen on \$PORT %d, which is reserved for the admin API`, port)))
+		})
+	})
+
+	Context("with an unknown format", func() {
+		It("exits with an error", func() {
+			// Replaces the process started by JustBeforeEach.
+			ginkgomon.Interrupt(process, 5*time.Second)
+
+			cmd := exec.Command(receiverBinPath, "-address="+address, "-format=yaml")
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Out).To(gbytes.Say(`Unknown format "yaml", expected text or json`))
+		})
+	})
+
+	Context("with an unknown mode", func() {
+		It("exits with an error", func() {
+			// Replaces the process started by JustBeforeEach.
+			ginkgomon.Interrupt(process, 5*time.Second)
+
+			cmd := exec.Command(receiverBinPath, "-address="+address, "-mode=whisper")
+			session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Out).To(gbytes.Say(`Unknown mode "whisper", expected one of ` + strings.Join([]string{"close-after", "delay", "echo", "greeting", "identify", "stream"}, ", ")))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package testrunner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	ginkgomon "github.com/tedsuo/ifrit/ginkgomon_v2"
+)
+
+type Args struct {
+	Address             string
+	ServerId            string
+	Mode                string
+	Format              string
+	Delay               time.Duration
+	Latency             time.Duration
+	CloseAfter          int
+	Greeting            string
+	Quiet               bool
+	ProxyProtocol       bool
+	ReportClientAddress bool
+	TLS                 bool
+	TLSSAN              string
+	DrainTimeout        time.Duration
+	// Port is exported to the receiver as $PORT when set.
+	Port uint16
+	// Env holds extra KEY=value environment variables, such as the
+	// CF_INSTANCE_* variables reported in JSON responses.
+	Env []string
+}
+
+func (args Args) ArgSlice() []string {
+	argSlice := []string{
+		"-address=" + args.Address,
+		"-serverId=" + args.ServerId,
+	}
+	if args.Mode != "" {
+		argSlice = append(argSlice, "-mode="+args.Mode)
+	}
+	if args.Format != "" {
+		argSlice = append(argSlice, "-format="+args.Format)
+	}
+	if args.Delay != 0 {
+		argSlice = append(argSlice, "-delay="+args.Delay.String())
+	}
+	if args.Latency != 0 {
+		argSlice = append(argSlice, "-latency="+args.Latency.String())
+	}
+	if args.CloseAfter != 0 {
+		argSlice = append(argSlice, "-closeAfter="+strconv.Itoa(args.CloseAfter))
+	}
+	if args.Greeting != "" {
+		argSlice = append(argSlice, "-greeting="+args.Greeting)
+	}
+	if args.Quiet {
+		argSlice = append(argSlice, "-quiet")
+	}
+	if args.ProxyProtocol {
+		argSlice = append(argSlice, "-proxyProtocol")
+	}
+	if args.ReportClientAddress {
+		argSlice = append(argSlice, "-reportClientAddress")
+	}
+	if args.TLS {
+		argSlice = append(argSlice, "-tls")
+	}
+	if args.TLSSAN != "" {
+		argSlice = append(argSlice, "-tlsSAN="+args.TLSSAN)
+	}
+	if args.DrainTimeout != 0 {
+		argSlice = append(argSlice, "-drainTimeout="+args.DrainTimeout.String())
+	}
+	return argSlice
+}
+
+func New(binPath string, args Args) *ginkgomon.Runner {
+	cmd := exec.Command(binPath, args.ArgSlice()...)
+	cmd.Env = os.Environ()
+	if args.Port != 0 {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("PORT=%d", args.Port))
+	} else {
+		cmd.Env = append(cmd.Env, "PORT=")
+	}
+	cmd.Env = append(cmd.Env, args.Env...)
+
+	return ginkgomon.New(ginkgomon.Config{
+		Name:              "tcp-receiver",
+		AnsiColorCode:     "1;96m",
+		StartCheck:        "Listening on",
+		StartCheckTimeout: 10 * time.Second,
+		Command:           cmd,
+	})
+}
@@ -0,0 +1,244 @@
+package accesslog
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const RTR_SOURCE = "[RTR/"
+
+// Entry is one Gorouter access log line, as it appears in the app's logs
+// with the RTR source.
+type Entry struct {
+	// RouterIndex is the index of the Gorouter instance that logged the
+	// request.
+	RouterIndex   string
+	Host          string
+	StartedAt     time.Time
+	Method        string
+	Path          string
+	Protocol      string
+	Status        int
+	BytesReceived int64
+	BytesSent     int64
+	Referer       string
+	UserAgent     string
+	RemoteAddr    string
+	// Destination is the backend address Gorouter sent the request to, and
+	// is empty when the request never reached one.
+	Destination   string
+	XForwardedFor []string
+	VcapRequestID string
+	ResponseTime  time.Duration
+	AppID         string
+	AppIndex      string
+	// Fields holds every key:value pair after the positional fields,
+	// including the ones parsed into the fields above. Values Gorouter
+	// logs as "-" are empty.
+	Fields map[string]string
+}
+
+// Parse parses a single log line. The line may carry the timestamp and
+// source prefix `cf logs` adds, or be the bare access log message.
+func Parse(line string) (Entry, error) {
+	var entry Entry
+	message := strings.TrimSpace(line)
+	if start := strings.Index(message, RTR_SOURCE); start >= 0 {
+		rest := message[start+len(RTR_SOURCE):]
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return Entry{}, fmt.Errorf("unterminated log source in %q", line)
+		}
+		entry.RouterIndex = rest[:end]
+		message = strings.TrimSpace(rest[end+1:])
+		message = strings.TrimSpace(strings.TrimPrefix(message, "OUT"))
+	}
+
+	s := scanner{input: message}
+	positional := make([]string, 11)
+	for i := range positional {
+		token, err := s.next()
+		if err != nil {
+			return Entry{}, fmt.Errorf("parsing access log %q: %w", line, err)
+		}
+		positional[i] = token
+	}
+
+	entry.Host = positional[0]
+	startedAt, err := time.Parse(time.RFC3339Nano, positional[2])
+	if err != nil {
+		return Entry{}, fmt.Errorf("parsing access log %q: start time: %w", line, err)
+	}
+	entry.StartedAt = startedAt
+
+	request := strings.Fields(positional[3])
+	if len(request) != 3 {
+		return Entry{}, fmt.Errorf("parsing access log %q: malformed request %q", line, positional[3])
+	}
+	entry.Method, entry.Path, entry.Protocol = request[0], request[1], request[2]
+
+	if entry.Status, err = strconv.Atoi(positional[4]); err != nil {
+		return Entry{}, fmt.Errorf("parsing access log %q: status: %w", line, err)
+	}
+	if entry.BytesReceived, err = strconv.ParseInt(positional[5], 10, 64); err != nil {
+		return Entry{}, fmt.Errorf("parsing access log %q: bytes received: %w", line, err)
+	}
+	if entry.BytesSent, err = strconv.ParseInt(positional[6], 10, 64); err != nil {
+		return Entry{}, fmt.Errorf("parsing access log %q: bytes sent: %w", line, err)
+	}
+	entry.Referer = dash(positional[7])
+	entry.UserAgent = dash(positional[8])
+	entry.RemoteAddr = dash(positional[9])
+	entry.Destination = dash(positional[10])
+
+	entry.Fields = map[string]string{}
+	for !s.done() {
+		key, value, err := s.field()
+		if err != nil {
+			return Entry{}, fmt.Errorf("parsing access log %q: %w", line, err)
+		}
+		entry.Fields[key] = dash(value)
+	}
+
+	for _, address := range strings.Split(entry.Fields["x_forwarded_for"], ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			entry.XForwardedFor = append(entry.XForwardedFor, address)
+		}
+	}
+	entry.VcapRequestID = entry.Fields["vcap_request_id"]
+	entry.AppID = entry.Fields["app_id"]
+	entry.AppIndex = entry.Fields["app_index"]
+	if responseTime := entry.Fields["response_time"]; responseTime != "" {
+		seconds, err := strconv.ParseFloat(responseTime, 64)
+		if err != nil {
+			return Entry{}, fmt.Errorf("parsing access log %q: response time: %w", line, err)
+		}
+		entry.ResponseTime = time.Duration(seconds * float64(time.Second))
+	}
+
+	return entry, nil
+}
+
+// ParseLogs parses every RTR line in logs, such as the output of
+// `cf logs --recent`, and skips all other lines. RTR lines that do not
+// parse are skipped too, so that one odd line does not hide the entries
+// around it, and their errors are returned in unparsed.
+func ParseLogs(logs string) (entries []Entry, unparsed []error, err error) {
+	lines := bufio.NewScanner(strings.NewReader(logs))
+	lines.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lines.Scan() {
+		if !strings.Contains(lines.Text(), RTR_SOURCE) {
+			continue
+		}
+		entry, err := Parse(lines.Text())
+		if err != nil {
+			unparsed = append(unparsed, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, unparsed, lines.Err()
+}
+
+// WithRequestID returns the entries logged for the request with id.
+func WithRequestID(entries []Entry, id string) []Entry {
+	var matching []Entry
+	for _, entry := range entries {
+		if entry.VcapRequestID == id {
+			matching = append(matching, entry)
+		}
+	}
+	return matching
+}
+
+func dash(value string) string {
+	if value == "-" {
+		return ""
+	}
+	return value
+}
+
+// scanner splits an access log message into bare words, "quoted strings"
+// and [bracketed] values.
+type scanner struct {
+	input string
+	pos   int
+}
+
+func (s *scanner) skipSpaces() {
+	for s.pos < len(s.input) && s.input[s.pos] == ' ' {
+		s.pos++
+	}
+}
+
+func (s *scanner) done() bool {
+	s.skipSpaces()
+	return s.pos >= len(s.input)
+}
+
+func (s *scanner) next() (string, error) {
+	if s.done() {
+		return "", fmt.Errorf("unexpected end of line at offset %d", s.pos)
+	}
+	switch s.input[s.pos] {
+	case '"':
+		return s.quoted()
+	case '[':
+		end := strings.IndexByte(s.input[s.pos:], ']')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated [ at offset %d", s.pos)
+		}
+		value := s.input[s.pos+1 : s.pos+end]
+		s.pos += end + 1
+		return value, nil
+	default:
+		return s.word(), nil
+	}
+}
+
+// field reads a key:value pair, where the value is quoted or a bare word.
+func (s *scanner) field() (string, string, error) {
+	s.skipSpaces()
+	colon := strings.IndexByte(s.input[s.pos:], ':')
+	if colon <= 0 || strings.ContainsAny(s.input[s.pos:s.pos+colon], ` "`) {
+		return "", "", fmt.Errorf("expected key:value at offset %d", s.pos)
+	}
+	key := s.input[s.pos : s.pos+colon]
+	s.pos += colon + 1
+	if s.pos < len(s.input) && s.input[s.pos] == '"' {
+		value, err := s.quoted()
+		return key, value, err
+	}
+	return key, s.word(), nil
+}
+
+func (s *scanner) word() string {
+	start := s.pos
+	for s.pos < len(s.input) && s.input[s.pos] != ' ' {
+		s.pos++
+	}
+	return s.input[start:s.pos]
+}
+
+func (s *scanner) quoted() (string, error) {
+	start := s.pos
+	var value strings.Builder
+	for s.pos++; s.pos < len(s.input); s.pos++ {
+		switch c := s.input[s.pos]; c {
+		case '\\':
+			if s.pos+1 < len(s.input) {
+				s.pos++
+				value.WriteByte(s.input[s.pos])
+			}
+		case '"':
+			s.pos++
+			return value.String(), nil
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated quote at offset %d", start)
+}
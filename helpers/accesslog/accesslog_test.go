@@ -0,0 +1,120 @@
+package accesslog_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/accesslog"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	routedLine  = `   2025-03-04T10:20:30.12+0000 [RTR/1] OUT rats-app.apps.example.com - [2025-03-04T10:20:30.123456789Z] "GET /request?x=1 HTTP/1.1" 200 12 1234 "-" "Go-http-client/1.1" "10.0.0.5:54321" "10.0.1.7:61012" x_forwarded_for:"203.0.113.9, 10.0.0.5" x_forwarded_proto:"https" vcap_request_id:"5c1e9b7a-1a2b-4c3d-8e9f-0a1b2c3d4e5f" response_time:0.004512 gorouter_time:0.000321 app_id:"0d6e7a50-3c4b-4f0e-9d3c-6f1e2a3b4c5d" app_index:"2" instance_id:"a1b2c3d4-e5f6-4a7b-8c9d-0e1f" x_cf_routererror:"-" x_b3_traceid:"463ac35c9f6413ad48485a3953bb6124" x_b3_spanid:"a2fb4a1d1a96d312" x_b3_parentspanid:"-" b3:"463ac35c9f6413ad48485a3953bb6124-a2fb4a1d1a96d312"`
+	unknownLine = `rats-app.apps.example.com - [2025-03-04T10:20:31.5Z] "POST /missing HTTP/2.0" 404 0 67 "https://example.com/" "curl/8.5.0 \"quoted\"" "10.0.0.5:54322" "-" x_forwarded_for:"10.0.0.5" vcap_request_id:"6d2f0c8b-2b3c-4d4e-9f0a-1b2c3d4e5f60" response_time:- app_id:"-" app_index:"-" x_cf_routererror:"unknown_route"`
+)
+
+var _ = Describe("Access log", func() {
+	Describe("Parse", func() {
+		It("parses a line from cf logs into its fields", func() {
+			entry, err := accesslog.Parse(routedLine)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(entry.RouterIndex).To(Equal("1"))
+			Expect(entry.Host).To(Equal("rats-app.apps.example.com"))
+			Expect(entry.StartedAt).To(Equal(time.Date(2025, 3, 4, 10, 20, 30, 123456789, time.UTC)))
+			Expect(entry.Method).To(Equal("GET"))
+			Expect(entry.Path).To(Equal("/request?x=1"))
+			Expect(entry.Protocol).To(Equal("HTTP/1.1"))
+			Expect(entry.Status).To(Equal(200))
+			Expect(entry.BytesReceived).To(Equal(int64(12)))
+			Expect(entry.BytesSent).To(Equal(int64(1234)))
+			Expect(entry.Referer).To(BeEmpty())
+			Expect(entry.UserAgent).To(Equal("Go-http-client/1.1"))
+			Expect(entry.RemoteAddr).To(Equal("10.0.0.5:54321"))
+			Expect(entry.Destination).To(Equal("10.0.1.7:61012"))
+			Expect(entry.XForwardedFor).To(Equal([]string{"203.0.113.9", "10.0.0.5"}))
+			Expect(entry.VcapRequestID).To(Equal("5c1e9b7a-1a2b-4c3d-8e9f-0a1b2c3d4e5f"))
+			Expect(entry.ResponseTime).To(Equal(4512 * time.Microsecond))
+			Expect(entry.AppID).To(Equal("0d6e7a50-3c4b-4f0e-9d3c-6f1e2a3b4c5d"))
+			Expect(entry.AppIndex).To(Equal("2"))
+			Expect(entry.Fields).To(HaveKeyWithValue("x_forwarded_proto", "https"))
+			Expect(entry.Fields).To(HaveKeyWithValue("gorouter_time", "0.000321"))
+			Expect(entry.Fields).To(HaveKeyWithValue("b3", "463ac35c9f6413ad48485a3953bb6124-a2fb4a1d1a96d312"))
+			Expect(entry.Fields).To(HaveKeyWithValue("x_cf_routererror", ""))
+		})
+
+		It("parses a bare message for a request that reached no app", func() {
+			entry, err := accesslog.Parse(unknownLine)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(entry.RouterIndex).To(BeEmpty())
+			Expect(entry.Method).To(Equal("POST"))
+			Expect(entry.Protocol).To(Equal("HTTP/2.0"))
+			Expect(entry.Status).To(Equal(404))
+			Expect(entry.Referer).To(Equal("https://example.com/"))
+			Expect(entry.UserAgent).To(Equal(`curl/8.5.0 "quoted"`))
+			Expect(entry.Destination).To(BeEmpty())
+			Expect(entry.ResponseTime).To(BeZero())
+			Expect(entry.AppID).To(BeEmpty())
+			Expect(entry.AppIndex).To(BeEmpty())
+			Expect(entry.Fields).To(HaveKeyWithValue("x_cf_routererror", "unknown_route"))
+		})
+
+		DescribeTable("rejects malformed lines",
+			func(line string) {
+				_, err := accesslog.Parse(line)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("truncated positional fields", `host - [2025-03-04T10:20:30Z] "GET / HTTP/1.1" 200`),
+			Entry("bad start time", `host - [yesterday] "GET / HTTP/1.1" 200 0 0 "-" "-" "-" "-"`),
+			Entry("bad request line", `host - [2025-03-04T10:20:30Z] "GET" 200 0 0 "-" "-" "-" "-"`),
+			Entry("non-numeric status", `host - [2025-03-04T10:20:30Z] "GET / HTTP/1.1" OK 0 0 "-" "-" "-" "-"`),
+			Entry("unterminated quote", `host - [2025-03-04T10:20:30Z] "GET / HTTP/1.1" 200 0 0 "-" "-" "-" "-" app_id:"abc`),
+			Entry("field without a key", `host - [2025-03-04T10:20:30Z] "GET / HTTP/1.1" 200 0 0 "-" "-" "-" "-" stray`),
+			Entry("bad response time", `host - [2025-03-04T10:20:30Z] "GET / HTTP/1.1" 200 0 0 "-" "-" "-" "-" response_time:fast`),
+		)
+	})
+
+	Describe("ParseLogs", func() {
+		It("parses only the RTR lines", func() {
+			logs := "Retrieving logs for app rats-app in org o / space s as admin...\n\n" +
+				routedLine + "\n" +
+				"   2025-03-04T10:20:30.13+0000 [APP/PROC/WEB/0] OUT Serving /request\n" +
+				"   2025-03-04T10:20:31.50+0000 [RTR/0] OUT " + unknownLine + "\n"
+
+			entries, unparsed, err := accesslog.ParseLogs(logs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unparsed).To(BeEmpty())
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].RouterIndex).To(Equal("1"))
+			Expect(entries[1].RouterIndex).To(Equal("0"))
+			Expect(entries[1].Status).To(Equal(404))
+		})
+
+		It("skips malformed RTR lines and reports them", func() {
+			logs := "   2025-03-04T10:20:30.12+0000 [RTR/0] OUT garbage\n" +
+				routedLine + "\n"
+
+			entries, unparsed, err := accesslog.ParseLogs(logs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].RouterIndex).To(Equal("1"))
+			Expect(unparsed).To(HaveLen(1))
+			Expect(unparsed[0]).To(MatchError(ContainSubstring("garbage")))
+		})
+	})
+
+	Describe("WithRequestID", func() {
+		It("keeps the entries for the request", func() {
+			routed, err := accesslog.Parse(routedLine)
+			Expect(err).NotTo(HaveOccurred())
+			unknown, err := accesslog.Parse(unknownLine)
+			Expect(err).NotTo(HaveOccurred())
+
+			entries := []accesslog.Entry{routed, unknown, routed}
+			Expect(accesslog.WithRequestID(entries, routed.VcapRequestID)).To(Equal([]accesslog.Entry{routed, routed}))
+			Expect(accesslog.WithRequestID(entries, "none")).To(BeEmpty())
+		})
+	})
+})
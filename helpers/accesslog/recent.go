@@ -0,0 +1,26 @@
+package accesslog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+)
+
+// Recent returns the access log entries in the recent logs of appName,
+// along with the errors of RTR lines that did not parse. Gorouter ships
+// the entries asynchronously, so callers should poll for the entries they
+// expect.
+func Recent(appName string, timeout time.Duration) ([]Entry, []error, error) {
+	session := cf.Cf("logs", appName, "--recent")
+	select {
+	case <-session.Exited:
+	case <-time.After(timeout):
+		session.Kill()
+		return nil, nil, fmt.Errorf("cf logs %s --recent did not exit within %s", appName, timeout)
+	}
+	if session.ExitCode() != 0 {
+		return nil, nil, fmt.Errorf("cf logs %s --recent exited with %d: %s", appName, session.ExitCode(), session.Err.Contents())
+	}
+	return ParseLogs(string(session.Out.Contents()))
+}
@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+
+	. "github.com/onsi/ginkgo/v2"
+)
+
+// ForEachAddress runs check against each address in turn, as a step named
+// label followed by the address, and reports whether it passed for each
+// address so that a failure on one IP family stands out.
+func ForEachAddress(addrs []addresses.Address, label string, check func(addr addresses.Address)) {
+	for _, addr := range addrs {
+		By(fmt.Sprintf("%s %s", label, addr))
+		func() {
+			passed := false
+			defer func() {
+				outcome := "failed"
+				if passed {
+					outcome = "passed"
+				}
+				AddReportEntry(fmt.Sprintf("%s %s", addr.Family, addr.Host), outcome)
+			}()
+
+			check(addr)
+			passed = true
+		}()
+	}
+}
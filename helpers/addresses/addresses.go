@@ -0,0 +1,106 @@
+package addresses
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	IPv4 = "ipv4"
+	IPv6 = "ipv6"
+
+	DEFAULT_LOOKUP_TIMEOUT = 10 * time.Second
+)
+
+// Address is a single router IP address, tested separately from any other
+// address its configured entry resolves to.
+type Address struct {
+	Host   string
+	Family string
+	// Entry is the configured address or DNS name this address came from.
+	Entry string
+}
+
+// JoinPort returns a dial target for port on this address, bracketing IPv6
+// literals as needed.
+func (a Address) JoinPort(port uint16) string {
+	return net.JoinHostPort(a.Host, strconv.Itoa(int(port)))
+}
+
+func (a Address) String() string {
+	if a.Entry == a.Host {
+		return fmt.Sprintf("%s (%s)", a.Host, a.Family)
+	}
+	return fmt.Sprintf("%s (%s, %s)", a.Host, a.Family, a.Entry)
+}
+
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Expand resolves every entry into its IP addresses, keeping only the given
+// families. IP literals are kept as they are; DNS names are expanded into
+// one Address per A and AAAA record.
+func Expand(entries []string, families []string) ([]Address, error) {
+	return ExpandWithResolver(net.DefaultResolver, entries, families)
+}
+
+func ExpandWithResolver(resolver Resolver, entries []string, families []string) ([]Address, error) {
+	wanted := map[string]bool{}
+	for _, family := range families {
+		family = strings.ToLower(family)
+		if family != IPv4 && family != IPv6 {
+			return nil, fmt.Errorf("unknown address family %q", family)
+		}
+		wanted[family] = true
+	}
+	if len(wanted) == 0 {
+		wanted[IPv4] = true
+		wanted[IPv6] = true
+	}
+
+	var expanded []Address
+	for _, entry := range entries {
+		host := strings.TrimSuffix(strings.TrimPrefix(entry, "["), "]")
+
+		var ips []net.IP
+		if ip := net.ParseIP(host); ip != nil {
+			ips = []net.IP{ip}
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_LOOKUP_TIMEOUT)
+			addrs, err := resolver.LookupIPAddr(ctx, host)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("resolving %s: %w", entry, err)
+			}
+			for _, addr := range addrs {
+				ips = append(ips, addr.IP)
+			}
+		}
+
+		found := false
+		for _, ip := range ips {
+			address := Address{Host: ip.String(), Family: Family(ip), Entry: entry}
+			if wanted[address.Family] {
+				expanded = append(expanded, address)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s has no addresses in families %v", entry, families)
+		}
+	}
+
+	return expanded, nil
+}
+
+func Family(ip net.IP) string {
+	if ip.To4() != nil {
+		return IPv4
+	}
+	return IPv6
+}
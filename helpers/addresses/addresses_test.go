@@ -0,0 +1,164 @@
+package addresses_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+	var addrs []net.IPAddr
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+var _ = Describe("Addresses", func() {
+	var resolver fakeResolver
+
+	BeforeEach(func() {
+		resolver = fakeResolver{
+			"tcp.example.com": {"10.0.0.1", "2001:db8::1", "2001:db8::2"},
+			"v4.example.com":  {"10.0.0.2"},
+		}
+	})
+
+	Describe("ExpandWithResolver", func() {
+		It("keeps IP literals of both families", func() {
+			expanded, err := addresses.ExpandWithResolver(resolver, []string{"10.0.0.9", "2001:db8::9", "[::1]"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expanded).To(Equal([]addresses.Address{
+				{Host: "10.0.0.9", Family: addresses.IPv4, Entry: "10.0.0.9"},
+				{Host: "2001:db8::9", Family: addresses.IPv6, Entry: "2001:db8::9"},
+				{Host: "::1", Family: addresses.IPv6, Entry: "[::1]"},
+			}))
+		})
+
+		It("expands DNS names into their A and AAAA records", func() {
+			expanded, err := addresses.ExpandWithResolver(resolver, []string{"tcp.example.com"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expanded).To(Equal([]addresses.Address{
+				{Host: "10.0.0.1", Family: addresses.IPv4, Entry: "tcp.example.com"},
+				{Host: "2001:db8::1", Family: addresses.IPv6, Entry: "tcp.example.com"},
+				{Host: "2001:db8::2", Family: addresses.IPv6, Entry: "tcp.example.com"},
+			}))
+		})
+
+		It("only keeps the requested families", func() {
+			expanded, err := addresses.ExpandWithResolver(resolver, []string{"tcp.example.com", "10.0.0.9"}, []string{"IPv4"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expanded).To(Equal([]addresses.Address{
+				{Host: "10.0.0.1", Family: addresses.IPv4, Entry: "tcp.example.com"},
+				{Host: "10.0.0.9", Family: addresses.IPv4, Entry: "10.0.0.9"},
+			}))
+		})
+
+		It("fails when an entry has no address in the requested families", func() {
+			_, err := addresses.ExpandWithResolver(resolver, []string{"v4.example.com"}, []string{addresses.IPv6})
+			Expect(err).To(MatchError(ContainSubstring("v4.example.com has no addresses")))
+		})
+
+		It("fails when a name does not resolve", func() {
+			_, err := addresses.ExpandWithResolver(resolver, []string{"missing.example.com"}, nil)
+			Expect(err).To(MatchError(ContainSubstring("resolving missing.example.com")))
+		})
+
+		It("fails on an unknown family", func() {
+			_, err := addresses.ExpandWithResolver(resolver, []string{"10.0.0.9"}, []string{"ipx"})
+			Expect(err).To(MatchError(`unknown address family "ipx"`))
+		})
+	})
+
+	Describe("Address", func() {
+		It("joins IPv4 hosts and ports", func() {
+			Expect(addresses.Address{Host: "10.0.0.1"}.JoinPort(1024)).To(Equal("10.0.0.1:1024"))
+		})
+
+		It("brackets IPv6 hosts", func() {
+			Expect(addresses.Address{Host: "2001:db8::1"}.JoinPort(1024)).To(Equal("[2001:db8::1]:1024"))
+		})
+
+		It("describes the family and the entry it was resolved from", func() {
+			Expect(addresses.Address{Host: "2001:db8::1", Family: addresses.IPv6, Entry: "tcp.example.com"}.String()).To(Equal("2001:db8::1 (ipv6, tcp.example.com)"))
+			Expect(addresses.Address{Host: "10.0.0.1", Family: addresses.IPv4, Entry: "10.0.0.1"}.String()).To(Equal("10.0.0.1 (ipv4)"))
+		})
+	})
+
+	Context("with a listener on ::1", func() {
+		var (
+			listener net.Listener
+			port     uint16
+			address  addresses.Address
+		)
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "[::1]:0")
+			if err != nil {
+				Skip(fmt.Sprintf("IPv6 loopback is unavailable: %s", err))
+			}
+			port = uint16(listener.Addr().(*net.TCPAddr).Port)
+
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						defer conn.Close()
+						_, _ = io.Copy(conn, conn)
+					}()
+				}
+			}()
+
+			expanded, err := addresses.Expand([]string{"::1"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expanded).To(HaveLen(1))
+			address = expanded[0]
+		})
+
+		AfterEach(func() {
+			if listener != nil {
+				listener.Close()
+			}
+		})
+
+		It("exchanges messages through the joined address", func() {
+			conn, err := net.DialTimeout(tcpclient.CONN_TYPE, address.JoinPort(port), tcpclient.DEFAULT_CONNECT_TIMEOUT)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			resp, err := tcpclient.Exchange(conn, []byte("hello"), tcpclient.DEFAULT_RW_TIMEOUT)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(resp)).To(Equal("hello"))
+		})
+
+		It("runs concurrent connections through the joined address", func() {
+			result := tcpclient.RunScale(address.JoinPort(port), tcpclient.ScaleOptions{Connections: 20})
+			Expect(result.Succeeded).To(Equal(20), result.String())
+		})
+
+		It("reports refused connections once the listener is gone", func() {
+			listener.Close()
+			result := tcpclient.RunScale(address.JoinPort(port), tcpclient.ScaleOptions{Connections: 2})
+			Expect(result.Refused).To(Equal(2), result.String())
+			Expect(errors.New(result.String())).To(MatchError(ContainSubstring("connection refused")))
+		})
+	})
+})
@@ -1,15 +1,129 @@
 package assets
 
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/gomega"
+)
+
+// ASSETS_DIR_ENV overrides the directory the assets are read from.
+const ASSETS_DIR_ENV = "RATS_ASSETS_DIR"
+
 type Assets struct {
-	TcpDropletReceiver string
-	TcpSampleReceiver  string
-	TcpSampleGolang    string
+	TcpReceiver     string
+	TcpSampleGolang string
+	GrpcEcho        string
+	RouteService    string
+	// Buildpack is the buildpack that stages these assets. It is empty for
+	// the asset sources, which need the Go buildpack.
+	Buildpack string
 }
 
+// NewAssets returns the asset sources, from $RATS_ASSETS_DIR when it is set
+// and otherwise from the assets directory of this module, wherever the
+// suites are run from.
 func NewAssets() Assets {
+	dir := assetsDir()
+	return Assets{
+		TcpReceiver:     filepath.Join(dir, "tcp-receiver"),
+		TcpSampleGolang: filepath.Join(dir, "golang"),
+		GrpcEcho:        filepath.Join(dir, "grpc-echo"),
+		RouteService:    filepath.Join(dir, "route-service"),
+	}
+}
+
+// Prebuilt returns the assets that Prebuild compiled into dir, to be staged
+// with buildpack.
+func Prebuilt(dir, buildpack string) Assets {
 	return Assets{
-		TcpDropletReceiver: "../assets/tcp-droplet-receiver/",
-		TcpSampleReceiver:  "../assets/tcp-sample-receiver/",
-		TcpSampleGolang:    "../assets/golang/",
+		TcpReceiver:     filepath.Join(dir, "tcp-receiver"),
+		TcpSampleGolang: filepath.Join(dir, "golang"),
+		GrpcEcho:        filepath.Join(dir, "grpc-echo"),
+		RouteService:    filepath.Join(dir, "route-service"),
+		Buildpack:       buildpack,
+	}
+}
+
+// Prebuild cross-compiles every asset for Linux into its own directory under
+// dir, ready to be pushed with the binary buildpack.
+func (a Assets) Prebuild(dir string) error {
+	for _, source := range []string{a.TcpReceiver, a.TcpSampleGolang, a.GrpcEcho, a.RouteService} {
+		err := prebuild(source, dir)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrebuildOnce prebuilds the assets into a new temporary directory when
+// enabled, and returns the directory for ForPush. Suites call it from the
+// first function of SynchronizedBeforeSuite, so that parallel processes
+// share one build, and remove the directory after the suite.
+func PrebuildOnce(enabled bool) []byte {
+	if !enabled {
+		return nil
+	}
+	dir, err := os.MkdirTemp("", "rats-assets")
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	ExpectWithOffset(1, NewAssets().Prebuild(dir)).To(Succeed())
+	return []byte(dir)
+}
+
+// ForPush returns the assets specs push: the ones PrebuildOnce built in
+// prebuiltDir, staged with binaryBuildpack, or the asset sources, staged
+// with goBuildpack, when nothing was prebuilt.
+func ForPush(prebuiltDir, binaryBuildpack, goBuildpack string) Assets {
+	if prebuiltDir != "" {
+		return Prebuilt(prebuiltDir, binaryBuildpack)
+	}
+	a := NewAssets()
+	a.Buildpack = goBuildpack
+	return a
+}
+
+func prebuild(source, dir string) error {
+	name := filepath.Base(filepath.Clean(source))
+	out := filepath.Join(dir, name)
+	err := os.MkdirAll(out, 0755)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "build", "-mod=vendor", "-o", filepath.Join(out, name), ".")
+	cmd.Dir = source
+	cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH=amd64", "CGO_ENABLED=0", "GOFLAGS=")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("building %s: %w\n%s", source, err, output)
+	}
+
+	// The binary buildpack needs a start command for apps pushed without -c.
+	err = os.WriteFile(filepath.Join(out, "Procfile"), []byte(fmt.Sprintf("web: ./%s\n", name)), 0644)
+	if err != nil {
+		return err
+	}
+	// The Go buildpack puts the binary on the PATH, and start commands such
+	// as "tcp-receiver --serverId=..." rely on that.
+	return os.WriteFile(filepath.Join(out, ".profile"), []byte("export PATH=\"$HOME:$PATH\"\n"), 0644)
+}
+
+func assetsDir() string {
+	if dir := os.Getenv(ASSETS_DIR_ENV); dir != "" {
+		return dir
+	}
+	_, file, _, ok := runtime.Caller(0)
+	if ok {
+		dir := filepath.Join(filepath.Dir(file), "..", "..", "assets")
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
 	}
+	// Binaries built with -trimpath have no source location; fall back to
+	// the layout the suites are run from.
+	return filepath.Join("..", "assets")
 }
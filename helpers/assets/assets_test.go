@@ -0,0 +1,123 @@
+package assets_test
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Assets", func() {
+	Describe("NewAssets", func() {
+		It("finds the assets of this module from any working directory", func() {
+			wd, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.Chdir, wd)
+			Expect(os.Chdir(os.TempDir())).To(Succeed())
+
+			a := assets.NewAssets()
+			Expect(filepath.Join(a.TcpReceiver, "main.go")).To(BeARegularFile())
+			Expect(filepath.Join(a.TcpSampleGolang, "site.go")).To(BeARegularFile())
+			Expect(filepath.Join(a.GrpcEcho, "echo.go")).To(BeARegularFile())
+			Expect(filepath.Join(a.RouteService, "main.go")).To(BeARegularFile())
+			Expect(a.Buildpack).To(BeEmpty())
+		})
+
+		It("reads the assets from $RATS_ASSETS_DIR when it is set", func() {
+			GinkgoT().Setenv(assets.ASSETS_DIR_ENV, "/var/vcap/packages/rats/assets")
+
+			a := assets.NewAssets()
+			Expect(a.TcpReceiver).To(Equal("/var/vcap/packages/rats/assets/tcp-receiver"))
+			Expect(a.TcpSampleGolang).To(Equal("/var/vcap/packages/rats/assets/golang"))
+			Expect(a.GrpcEcho).To(Equal("/var/vcap/packages/rats/assets/grpc-echo"))
+			Expect(a.RouteService).To(Equal("/var/vcap/packages/rats/assets/route-service"))
+		})
+	})
+
+	Describe("Prebuild", func() {
+		var source, dir string
+
+		writeAsset := func(name string) string {
+			assetDir := filepath.Join(source, name)
+			Expect(os.MkdirAll(assetDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(assetDir, "go.mod"), []byte("module example.com/"+name+"\n\ngo 1.23\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(assetDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)).To(Succeed())
+			return assetDir
+		}
+
+		BeforeEach(func() {
+			source = GinkgoT().TempDir()
+			dir = GinkgoT().TempDir()
+		})
+
+		It("cross-compiles every asset for Linux, ready for the binary buildpack", func() {
+			a := assets.Assets{
+				TcpReceiver:     writeAsset("tcp-receiver"),
+				TcpSampleGolang: writeAsset("golang"),
+				GrpcEcho:        writeAsset("grpc-echo"),
+				RouteService:    writeAsset("route-service"),
+			}
+			Expect(a.Prebuild(dir)).To(Succeed())
+
+			prebuilt := assets.Prebuilt(dir, "binary_buildpack")
+			Expect(prebuilt.Buildpack).To(Equal("binary_buildpack"))
+			for name, assetDir := range map[string]string{"tcp-receiver": prebuilt.TcpReceiver, "golang": prebuilt.TcpSampleGolang, "grpc-echo": prebuilt.GrpcEcho, "route-service": prebuilt.RouteService} {
+				binary, err := elf.Open(filepath.Join(assetDir, name))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(binary.Machine).To(Equal(elf.EM_X86_64))
+				binary.Close()
+
+				Expect(os.ReadFile(filepath.Join(assetDir, "Procfile"))).To(BeEquivalentTo("web: ./" + name + "\n"))
+				Expect(os.ReadFile(filepath.Join(assetDir, ".profile"))).To(ContainSubstring(`export PATH="$HOME:$PATH"`))
+			}
+		})
+
+		It("reports compile errors", func() {
+			broken := writeAsset("tcp-receiver")
+			Expect(os.WriteFile(filepath.Join(broken, "main.go"), []byte("package main\n\nfunc main() { undefined() }\n"), 0644)).To(Succeed())
+
+			err := assets.Assets{TcpReceiver: broken, TcpSampleGolang: writeAsset("golang"), GrpcEcho: writeAsset("grpc-echo"), RouteService: writeAsset("route-service")}.Prebuild(dir)
+			Expect(err).To(MatchError(ContainSubstring("undefined: undefined")))
+		})
+	})
+
+	Describe("PrebuildOnce", func() {
+		It("builds nothing unless enabled", func() {
+			Expect(assets.PrebuildOnce(false)).To(BeNil())
+		})
+
+		It("prebuilds the assets into a new directory", func() {
+			source := GinkgoT().TempDir()
+			for _, name := range []string{"tcp-receiver", "golang", "grpc-echo", "route-service"} {
+				assetDir := filepath.Join(source, name)
+				Expect(os.MkdirAll(assetDir, 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(assetDir, "go.mod"), []byte("module example.com/"+name+"\n\ngo 1.23\n"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(assetDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)).To(Succeed())
+			}
+			GinkgoT().Setenv(assets.ASSETS_DIR_ENV, source)
+
+			dir := string(assets.PrebuildOnce(true))
+			DeferCleanup(os.RemoveAll, dir)
+			Expect(dir).NotTo(BeEmpty())
+			Expect(filepath.Join(dir, "tcp-receiver", "tcp-receiver")).To(BeARegularFile())
+			Expect(filepath.Join(dir, "route-service", "Procfile")).To(BeARegularFile())
+		})
+	})
+
+	Describe("ForPush", func() {
+		It("pushes the prebuilt assets with the binary buildpack", func() {
+			a := assets.ForPush("/tmp/rats-assets", "binary_buildpack", "go_buildpack")
+			Expect(a).To(Equal(assets.Prebuilt("/tmp/rats-assets", "binary_buildpack")))
+		})
+
+		It("pushes the asset sources with the Go buildpack when nothing was prebuilt", func() {
+			a := assets.ForPush("", "binary_buildpack", "go_buildpack")
+			Expect(a.TcpReceiver).To(Equal(assets.NewAssets().TcpReceiver))
+			Expect(a.Buildpack).To(Equal("go_buildpack"))
+		})
+	})
+})
@@ -0,0 +1,145 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	ECHO_METHOD = "/echo.Echo/Echo"
+	CHAT_METHOD = "/echo.Echo/Chat"
+)
+
+var chatStreamDesc = &grpc.StreamDesc{
+	StreamName:    "Chat",
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+// Identity is what the grpc-echo asset reports in the response headers of
+// every call.
+type Identity struct {
+	ServerId string
+	// Transport is "tls" when the call reached the app over TLS, and "h2c"
+	// when it arrived in cleartext.
+	Transport string
+}
+
+func identityFrom(header metadata.MD) Identity {
+	var id Identity
+	if v := header.Get("server-id"); len(v) > 0 {
+		id.ServerId = v[0]
+	}
+	if v := header.Get("transport"); len(v) > 0 {
+		id.Transport = v[0]
+	}
+	return id
+}
+
+// Client calls the echo.Echo service of the grpc-echo asset.
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// NewClient returns a client for the service at target, in host:port form.
+// It speaks cleartext HTTP/2 when tlsConfig is nil, and TLS otherwise.
+func NewClient(target string, tlsConfig *tls.Config) (*Client, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient("passthrough:///"+target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Echo makes a unary call and returns the reply along with the identity of
+// the server that answered.
+func (c *Client) Echo(ctx context.Context, message string) (string, Identity, error) {
+	var header metadata.MD
+	reply := new(wrapperspb.StringValue)
+	err := c.conn.Invoke(ctx, ECHO_METHOD, wrapperspb.String(message), reply, grpc.Header(&header))
+	if err != nil {
+		return "", Identity{}, err
+	}
+	return reply.GetValue(), identityFrom(header), nil
+}
+
+// Chat sends every message on a single bidirectional stream while reading the
+// replies concurrently, and returns the replies in the order they arrived.
+func (c *Client) Chat(ctx context.Context, messages []string) ([]string, Identity, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.conn.NewStream(ctx, chatStreamDesc, CHAT_METHOD)
+	if err != nil {
+		return nil, Identity{}, err
+	}
+
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, message := range messages {
+			err := stream.SendMsg(wrapperspb.String(message))
+			if err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+
+	var replies []string
+	for {
+		reply := new(wrapperspb.StringValue)
+		err := stream.RecvMsg(reply)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return replies, Identity{}, err
+		}
+		replies = append(replies, reply.GetValue())
+	}
+
+	err = <-sendErr
+	if err != nil {
+		return replies, Identity{}, err
+	}
+	header, err := stream.Header()
+	if err != nil {
+		return replies, Identity{}, err
+	}
+	return replies, identityFrom(header), nil
+}
+
+// ChatMessages returns count distinct messages of size bytes each, numbered so
+// that reordered or corrupted replies can be told apart.
+func ChatMessages(count, size int) []string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz"
+	messages := make([]string, count)
+	for i := range messages {
+		prefix := fmt.Sprintf("message %06d:", i)
+		var b strings.Builder
+		b.WriteString(prefix)
+		for j := len(prefix); j < size; j++ {
+			b.WriteByte(alphabet[(i+j)%len(alphabet)])
+		}
+		messages[i] = b.String()
+	}
+	return messages
+}
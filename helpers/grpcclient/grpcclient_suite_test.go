@@ -0,0 +1,13 @@
+package grpcclient_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGrpcclient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Grpcclient Suite")
+}
@@ -0,0 +1,115 @@
+package grpcclient_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/grpcclient"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeEcho serves the echo.Echo service the way the grpc-echo asset does.
+var fakeEcho = grpc.ServiceDesc{
+	ServiceName: "echo.Echo",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(wrapperspb.StringValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			_ = grpc.SetHeader(ctx, metadata.Pairs("server-id", "fake", "transport", "h2c"))
+			return in, nil
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName: "Chat",
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			_ = stream.SetHeader(metadata.Pairs("server-id", "fake", "transport", "h2c"))
+			for {
+				in := new(wrapperspb.StringValue)
+				err := stream.RecvMsg(in)
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := stream.SendMsg(in); err != nil {
+					return err
+				}
+			}
+		},
+		ServerStreams: true,
+		ClientStreams: true,
+	}},
+}
+
+var _ = Describe("Client", func() {
+	var (
+		client *grpcclient.Client
+		ctx    context.Context
+	)
+
+	BeforeEach(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		server := grpc.NewServer()
+		server.RegisterService(&fakeEcho, nil)
+		go server.Serve(listener)
+		DeferCleanup(server.Stop)
+
+		client, err = grpcclient.NewClient(listener.Addr().String(), nil)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(client.Close)
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		DeferCleanup(cancel)
+	})
+
+	It("makes unary calls and reports the server identity", func() {
+		reply, identity, err := client.Echo(ctx, "hello")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reply).To(Equal("hello"))
+		Expect(identity).To(Equal(grpcclient.Identity{ServerId: "fake", Transport: "h2c"}))
+	})
+
+	It("returns the replies on a stream in the order they arrived", func() {
+		messages := grpcclient.ChatMessages(500, 4096)
+		replies, identity, err := client.Chat(ctx, messages)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replies).To(Equal(messages))
+		Expect(identity.ServerId).To(Equal("fake"))
+	})
+
+	It("fails when the service is unavailable", func() {
+		unavailable, err := grpcclient.NewClient("127.0.0.1:1", nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer unavailable.Close()
+
+		_, _, err = unavailable.Echo(ctx, "hello")
+		Expect(err).To(MatchError(ContainSubstring("Unavailable")))
+	})
+})
+
+var _ = Describe("ChatMessages", func() {
+	It("returns distinct messages of the requested size", func() {
+		messages := grpcclient.ChatMessages(3, 32)
+		Expect(messages).To(HaveLen(3))
+		for i, message := range messages {
+			Expect(message).To(HaveLen(32))
+			Expect(strings.Count(strings.Join(messages, ""), message)).To(Equal(1), messages[i])
+		}
+		Expect(messages[1]).To(HavePrefix("message 000001:"))
+	})
+})
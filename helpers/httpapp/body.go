@@ -0,0 +1,72 @@
+package httpapp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	// BODY_ALPHABET is repeated in the bodies the golang asset sends.
+	BODY_ALPHABET = "abcdefghijklmnopqrstuvwxyz"
+	// SHA256_HEADER carries the SHA-256 of the golang asset's uncompressed
+	// response body.
+	SHA256_HEADER = "X-Body-Sha256"
+)
+
+// UploadInfo is what the golang asset's /upload endpoint reports about a
+// request body.
+type UploadInfo struct {
+	Bytes            int64    `json:"bytes"`
+	SHA256           string   `json:"sha256"`
+	ContentLength    int64    `json:"content_length"`
+	TransferEncoding []string `json:"transfer_encoding"`
+}
+
+// DecodeUploadInfo parses a response body from the /upload endpoint.
+func DecodeUploadInfo(body []byte) (UploadInfo, error) {
+	var info UploadInfo
+	err := json.Unmarshal(body, &info)
+	if err != nil {
+		return UploadInfo{}, fmt.Errorf("decoding upload info %q: %w", body, err)
+	}
+	if info.SHA256 == "" {
+		return UploadInfo{}, fmt.Errorf("response is not upload info: %q", body)
+	}
+	return info, nil
+}
+
+// NewBody returns a reader of size bytes of the repeating alphabet, the same
+// body the golang asset's /bytes, /chunked and /gzip endpoints send.
+func NewBody(size int64) io.Reader {
+	return &alphabetReader{remaining: size}
+}
+
+// BodySHA256 returns the hex SHA-256 of the body NewBody(size) reads.
+func BodySHA256(size int64) string {
+	hash := sha256.New()
+	_, _ = io.Copy(hash, NewBody(size))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type alphabetReader struct {
+	offset    int64
+	remaining int64
+}
+
+func (r *alphabetReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	for i := range p {
+		p[i] = BODY_ALPHABET[(r.offset+int64(i))%int64(len(BODY_ALPHABET))]
+	}
+	r.offset += int64(len(p))
+	r.remaining -= int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,68 @@
+package httpapp_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bodies", func() {
+	Describe("NewBody", func() {
+		It("repeats the alphabet for the given size", func() {
+			body, err := io.ReadAll(httpapp.NewBody(30))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(Equal(httpapp.BODY_ALPHABET + "abcd"))
+		})
+
+		It("continues the alphabet across small reads", func() {
+			body, err := io.ReadAll(io.LimitReader(httpapp.NewBody(100), 100))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(Equal(strings.Repeat(httpapp.BODY_ALPHABET, 4)[:100]))
+
+			buf := make([]byte, 7)
+			reader := httpapp.NewBody(10)
+			n, err := reader.Read(buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(buf[:n])).To(Equal("abcdefg"))
+			n, err = reader.Read(buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(buf[:n])).To(Equal("hij"))
+			_, err = reader.Read(buf)
+			Expect(err).To(Equal(io.EOF))
+		})
+
+		It("is empty for size 0", func() {
+			body, err := io.ReadAll(httpapp.NewBody(0))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(BeEmpty())
+		})
+	})
+
+	Describe("BodySHA256", func() {
+		It("hashes the body", func() {
+			hash := sha256.Sum256([]byte(strings.Repeat(httpapp.BODY_ALPHABET, 3)))
+			Expect(httpapp.BodySHA256(78)).To(Equal(hex.EncodeToString(hash[:])))
+		})
+	})
+
+	Describe("DecodeUploadInfo", func() {
+		It("decodes a response from the /upload endpoint", func() {
+			info, err := httpapp.DecodeUploadInfo([]byte(`{"bytes":5,"sha256":"abc","content_length":-1,"transfer_encoding":["chunked"]}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(httpapp.UploadInfo{Bytes: 5, SHA256: "abc", ContentLength: -1, TransferEncoding: []string{"chunked"}}))
+		})
+
+		It("rejects other responses", func() {
+			_, err := httpapp.DecodeUploadInfo([]byte(`{"method":"POST"}`))
+			Expect(err).To(HaveOccurred())
+			_, err = httpapp.DecodeUploadInfo([]byte(`502 Bad Gateway`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,33 @@
+package httpapp
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// NewRouterClient returns a client that sends every request to routerIP,
+// whatever host the URL names, keeping the URL's port. The Host header and
+// TLS server name still come from the URL, so routers see the request as if
+// DNS had resolved the app's hostname to routerIP. An empty routerIP resolves
+// hostnames through DNS as usual.
+func NewRouterClient(routerIP string, tlsConfig *tls.Config, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: true,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if routerIP != "" {
+				_, port, err := net.SplitHostPort(addr)
+				if err != nil {
+					return nil, err
+				}
+				addr = net.JoinHostPort(routerIP, port)
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
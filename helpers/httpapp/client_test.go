@@ -0,0 +1,52 @@
+package httpapp_test
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("NewRouterClient", func() {
+	var (
+		server *ghttp.Server
+		port   string
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		DeferCleanup(server.Close)
+		u, err := url.Parse(server.URL())
+		Expect(err).NotTo(HaveOccurred())
+		port = u.Port()
+	})
+
+	It("sends requests for any host to the router, keeping the Host header", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/request"),
+			func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Host).To(Equal("app.apps.example.invalid:" + port))
+			},
+		))
+
+		client := httpapp.NewRouterClient("127.0.0.1", nil, 5*time.Second)
+		resp, err := client.Get("http://app.apps.example.invalid:" + port + "/request")
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("resolves hostnames through DNS without a router address", func() {
+		server.AllowUnhandledRequests = true
+		client := httpapp.NewRouterClient("", nil, 5*time.Second)
+		resp, err := client.Get("http://localhost:" + port + "/")
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+})
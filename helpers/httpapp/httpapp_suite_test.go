@@ -0,0 +1,13 @@
+package httpapp_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHttpapp(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Httpapp Suite")
+}
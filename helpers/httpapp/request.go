@@ -0,0 +1,72 @@
+package httpapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RequestInfo is the request as the golang asset received it, reported by
+// its /request, /delay and /status endpoints.
+type RequestInfo struct {
+	Method        string      `json:"method"`
+	Host          string      `json:"host"`
+	Path          string      `json:"path"`
+	RawQuery      string      `json:"raw_query"`
+	Proto         string      `json:"proto"`
+	RemoteAddr    string      `json:"remote_addr"`
+	Headers       http.Header `json:"headers"`
+	TLS           *TLSInfo    `json:"tls"`
+	InstanceIndex string      `json:"instance_index"`
+	InstanceGUID  string      `json:"instance_guid"`
+	AppName       string      `json:"app_name"`
+}
+
+// TLSInfo is only set when the app itself terminated TLS.
+type TLSInfo struct {
+	Version            string `json:"version"`
+	CipherSuite        string `json:"cipher_suite"`
+	ServerName         string `json:"server_name"`
+	NegotiatedProtocol string `json:"negotiated_protocol"`
+}
+
+// DecodeRequestInfo parses a response body from the golang asset.
+func DecodeRequestInfo(body []byte) (RequestInfo, error) {
+	var info RequestInfo
+	err := json.Unmarshal(body, &info)
+	if err != nil {
+		return RequestInfo{}, fmt.Errorf("decoding request info %q: %w", body, err)
+	}
+	if info.Method == "" {
+		return RequestInfo{}, fmt.Errorf("response is not request info: %q", body)
+	}
+	return info, nil
+}
+
+// GetRequestInfo sends a GET request to url and decodes the request info
+// the app responds with, along with the status code.
+func GetRequestInfo(client *http.Client, url string) (RequestInfo, int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return RequestInfo{}, 0, err
+	}
+	return DoRequestInfo(client, req)
+}
+
+// DoRequestInfo sends req and decodes the request info the app responds
+// with, along with the status code.
+func DoRequestInfo(client *http.Client, req *http.Request) (RequestInfo, int, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return RequestInfo{}, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return RequestInfo{}, resp.StatusCode, err
+	}
+	info, err := DecodeRequestInfo(body)
+	return info, resp.StatusCode, err
+}
@@ -0,0 +1,82 @@
+package httpapp_test
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+const requestInfo = `{
+  "method": "GET",
+  "host": "app.example.com",
+  "path": "/request",
+  "raw_query": "x=1",
+  "proto": "HTTP/1.1",
+  "remote_addr": "10.0.1.4:51234",
+  "headers": {
+    "X-Forwarded-For": ["203.0.113.7, 10.0.1.4"],
+    "X-Forwarded-Proto": ["https"]
+  },
+  "tls": null,
+  "instance_index": "1",
+  "instance_guid": "6f3e2a1c-0d4b-4a8e-9b7c-2f1e0a9d8c7b",
+  "app_name": "rats-app"
+}`
+
+var _ = Describe("RequestInfo", func() {
+	It("decodes a response from the golang asset", func() {
+		info, err := httpapp.DecodeRequestInfo([]byte(requestInfo))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Host).To(Equal("app.example.com"))
+		Expect(info.RawQuery).To(Equal("x=1"))
+		Expect(info.Headers.Get("X-Forwarded-Proto")).To(Equal("https"))
+		Expect(info.Headers.Values("X-Forwarded-For")).To(Equal([]string{"203.0.113.7, 10.0.1.4"}))
+		Expect(info.TLS).To(BeNil())
+		Expect(info.InstanceIndex).To(Equal("1"))
+		Expect(info.AppName).To(Equal("rats-app"))
+	})
+
+	It("rejects other responses", func() {
+		_, err := httpapp.DecodeRequestInfo([]byte("go, world\n"))
+		Expect(err).To(HaveOccurred())
+
+		_, err = httpapp.DecodeRequestInfo([]byte(`{"status":"ok"}`))
+		Expect(err).To(MatchError(ContainSubstring("response is not request info")))
+	})
+
+	It("gets request info with the status code", func() {
+		server := ghttp.NewServer()
+		defer server.Close()
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/status/503"),
+			ghttp.RespondWith(http.StatusServiceUnavailable, requestInfo),
+		))
+
+		info, status, err := httpapp.GetRequestInfo(http.DefaultClient, server.URL()+"/status/503")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(http.StatusServiceUnavailable))
+		Expect(info.Method).To(Equal("GET"))
+	})
+
+	It("sends requests with their headers", func() {
+		server := ghttp.NewServer()
+		defer server.Close()
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/request"),
+			ghttp.VerifyHeaderKV("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"),
+			ghttp.RespondWith(http.StatusOK, requestInfo),
+		))
+
+		req, err := http.NewRequest(http.MethodGet, server.URL()+"/request", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		info, status, err := httpapp.DoRequestInfo(http.DefaultClient, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(info.AppName).To(Equal("rats-app"))
+	})
+})
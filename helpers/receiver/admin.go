@@ -0,0 +1,167 @@
+package receiver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	ADMIN_PORT            = 8080
+	DEFAULT_ADMIN_TIMEOUT = 10 * time.Second
+	// ADMIN_TOKEN_ENV is the variable a receiver reads its admin token from.
+	// Every admin request but GET /ready must send the token in
+	// ADMIN_TOKEN_HEADER.
+	ADMIN_TOKEN_ENV    = "RECEIVER_ADMIN_TOKEN"
+	ADMIN_TOKEN_HEADER = "X-Receiver-Admin-Token"
+)
+
+// AdminState is the tcp-receiver state reported by its admin API.
+type AdminState struct {
+	Ready             bool     `json:"ready"`
+	Refusing          bool     `json:"refusing"`
+	Latency           string   `json:"latency"`
+	Listeners         []string `json:"listeners"`
+	ActiveConnections int      `json:"active_connections"`
+}
+
+// Counters are the traffic counters tcp-receiver reports, in total and for
+// each listener.
+type Counters struct {
+	AcceptedConnections int64 `json:"accepted_connections"`
+	RefusedConnections  int64 `json:"refused_connections"`
+	ActiveConnections   int64 `json:"active_connections"`
+	Messages            int64 `json:"messages"`
+	BytesIn             int64 `json:"bytes_in"`
+	BytesOut            int64 `json:"bytes_out"`
+}
+
+type Metrics struct {
+	Counters
+	// Listeners holds the counters of each listener, keyed by the address
+	// the receiver was started with, such as 0.0.0.0:3333.
+	Listeners map[string]Counters `json:"listeners"`
+}
+
+// AdminClient drives the admin API that tcp-receiver serves on $PORT, to
+// inspect and change how a running receiver behaves.
+type AdminClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewAdminClient returns a client for the admin API reachable at address, in
+// host:port form, of a receiver pushed with token in ADMIN_TOKEN_ENV.
+func NewAdminClient(address, token string) *AdminClient {
+	return &AdminClient{
+		baseURL:    "http://" + address,
+		token:      token,
+		httpClient: &http.Client{Timeout: DEFAULT_ADMIN_TIMEOUT},
+	}
+}
+
+// NewAdminToken returns a random token to push a receiver with, so that
+// only the spec that pushed it can drive its admin API.
+func NewAdminToken() string {
+	token := make([]byte, 16)
+	_, err := rand.Read(token)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(token)
+}
+
+func (c *AdminClient) State() (AdminState, error) {
+	var state AdminState
+	err := c.do(http.MethodGet, "/state", nil, http.StatusOK, &state)
+	return state, err
+}
+
+func (c *AdminClient) Metrics() (Metrics, error) {
+	var metrics Metrics
+	err := c.do(http.MethodGet, "/metrics", nil, http.StatusOK, &metrics)
+	return metrics, err
+}
+
+// Ready reports whether the receiver's readiness endpoint succeeds.
+func (c *AdminClient) Ready() (bool, error) {
+	resp, err := c.httpClient.Get(c.baseURL + "/ready")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+func (c *AdminClient) SetReady(ready bool) error {
+	return c.do(http.MethodPut, "/ready", url.Values{"ready": {strconv.FormatBool(ready)}}, http.StatusOK, nil)
+}
+
+// SetRefuse makes the receiver reset every new connection as soon as it is
+// accepted, or stop doing so.
+func (c *AdminClient) SetRefuse(refuse bool) error {
+	return c.do(http.MethodPut, "/refuse", url.Values{"refuse": {strconv.FormatBool(refuse)}}, http.StatusOK, nil)
+}
+
+// SetLatency delays every response by latency. Zero removes the delay.
+func (c *AdminClient) SetLatency(latency time.Duration) error {
+	return c.do(http.MethodPut, "/latency", url.Values{"latency": {latency.String()}}, http.StatusOK, nil)
+}
+
+// CloseListener stops the receiver listening on address, one of the
+// addresses it was started with.
+func (c *AdminClient) CloseListener(address string) error {
+	return c.do(http.MethodDelete, "/listeners", url.Values{"address": {address}}, http.StatusNoContent, nil)
+}
+
+// ResetConnections closes every active connection with a TCP RST and returns
+// how many were reset.
+func (c *AdminClient) ResetConnections() (int, error) {
+	var result struct {
+		Reset int `json:"reset"`
+	}
+	err := c.do(http.MethodDelete, "/connections", nil, http.StatusOK, &result)
+	return result.Reset, err
+}
+
+// Crash makes the receiver exit with exitCode shortly after responding.
+func (c *AdminClient) Crash(exitCode int) error {
+	return c.do(http.MethodPost, "/crash", url.Values{"exit_code": {strconv.Itoa(exitCode)}}, http.StatusAccepted, nil)
+}
+
+func (c *AdminClient) do(method, path string, query url.Values, expectedStatus int, result interface{}) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(ADMIN_TOKEN_HEADER, c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != expectedStatus {
+		return fmt.Errorf("%s %s: expected status %d, got %d: %s", method, path, expectedStatus, resp.StatusCode, body)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(body, result)
+}
@@ -0,0 +1,114 @@
+package receiver_test
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/receiver"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("AdminClient", func() {
+	var (
+		server *ghttp.Server
+		client *receiver.AdminClient
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		client = receiver.NewAdminClient(strings.TrimPrefix(server.URL(), "http://"), "admin-token")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("sends the admin token with every request", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("PUT", "/latency", "latency=1s"),
+			ghttp.VerifyHeaderKV(receiver.ADMIN_TOKEN_HEADER, "admin-token"),
+			ghttp.RespondWith(http.StatusOK, "{}"),
+		))
+
+		Expect(client.SetLatency(time.Second)).To(Succeed())
+	})
+
+	It("reads the receiver state", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/state"),
+			ghttp.RespondWith(http.StatusOK, `{"ready":true,"refusing":false,"latency":"0s","listeners":["0.0.0.0:3333"],"active_connections":2}`),
+		))
+
+		Expect(client.State()).To(Equal(receiver.AdminState{
+			Ready:             true,
+			Latency:           "0s",
+			Listeners:         []string{"0.0.0.0:3333"},
+			ActiveConnections: 2,
+		}))
+	})
+
+	It("reads the traffic counters", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/metrics"),
+			ghttp.RespondWith(http.StatusOK, `{"accepted_connections":3,"refused_connections":0,"active_connections":1,"messages":4,"bytes_in":20,"bytes_out":52,"listeners":{"0.0.0.0:3434":{"accepted_connections":0,"refused_connections":0,"active_connections":0,"messages":0,"bytes_in":0,"bytes_out":0},"0.0.0.0:3535":{"accepted_connections":3,"refused_connections":0,"active_connections":1,"messages":4,"bytes_in":20,"bytes_out":52}}}`),
+		))
+
+		counters := receiver.Counters{AcceptedConnections: 3, ActiveConnections: 1, Messages: 4, BytesIn: 20, BytesOut: 52}
+		Expect(client.Metrics()).To(Equal(receiver.Metrics{
+			Counters: counters,
+			Listeners: map[string]receiver.Counters{
+				"0.0.0.0:3434": {},
+				"0.0.0.0:3535": counters,
+			},
+		}))
+	})
+
+	It("reports readiness from the status code", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(ghttp.VerifyRequest("GET", "/ready"), ghttp.RespondWith(http.StatusOK, "ready")),
+			ghttp.CombineHandlers(ghttp.VerifyRequest("GET", "/ready"), ghttp.RespondWith(http.StatusServiceUnavailable, "not ready")),
+		)
+
+		Expect(client.Ready()).To(BeTrue())
+		Expect(client.Ready()).To(BeFalse())
+	})
+
+	It("sends each fault as a query parameter", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(ghttp.VerifyRequest("PUT", "/ready", "ready=false"), ghttp.RespondWith(http.StatusOK, "{}")),
+			ghttp.CombineHandlers(ghttp.VerifyRequest("PUT", "/refuse", "refuse=true"), ghttp.RespondWith(http.StatusOK, "{}")),
+			ghttp.CombineHandlers(ghttp.VerifyRequest("PUT", "/latency", "latency=1.5s"), ghttp.RespondWith(http.StatusOK, "{}")),
+			ghttp.CombineHandlers(ghttp.VerifyRequest("DELETE", "/listeners", "address=0.0.0.0%3A3333"), ghttp.RespondWith(http.StatusNoContent, "")),
+			ghttp.CombineHandlers(ghttp.VerifyRequest("DELETE", "/connections"), ghttp.RespondWith(http.StatusOK, `{"reset":3}`)),
+			ghttp.CombineHandlers(ghttp.VerifyRequest("POST", "/crash", "exit_code=2"), ghttp.RespondWith(http.StatusAccepted, "")),
+		)
+
+		Expect(client.SetReady(false)).To(Succeed())
+		Expect(client.SetRefuse(true)).To(Succeed())
+		Expect(client.SetLatency(1500 * time.Millisecond)).To(Succeed())
+		Expect(client.CloseListener("0.0.0.0:3333")).To(Succeed())
+		Expect(client.ResetConnections()).To(Equal(3))
+		Expect(client.Crash(2)).To(Succeed())
+		Expect(server.ReceivedRequests()).To(HaveLen(6))
+	})
+
+	It("returns the response body on an unexpected status", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("DELETE", "/listeners"),
+			ghttp.RespondWith(http.StatusNotFound, `not listening on "0.0.0.0:4444"`),
+		))
+
+		err := client.CloseListener("0.0.0.0:4444")
+		Expect(err).To(MatchError(`DELETE /listeners: expected status 204, got 404: not listening on "0.0.0.0:4444"`))
+	})
+
+	It("generates a different token for every push", func() {
+		token := receiver.NewAdminToken()
+		Expect(token).To(HaveLen(32))
+		Expect(receiver.NewAdminToken()).NotTo(Equal(token))
+	})
+})
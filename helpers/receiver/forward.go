@@ -0,0 +1,84 @@
+package receiver
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+)
+
+const PORT_FORWARD_POLL_INTERVAL = 500 * time.Millisecond
+
+// ConnectAdmin returns a client for the admin API of appName, pushed with
+// token, reached at host through the app's HTTP route. If the route does not answer within
+// timeout it falls back to forwarding a local port to the app's $PORT with
+// cf ssh. The returned function stops any port forwarding.
+func ConnectAdmin(appName, host, token string, timeout time.Duration) (*AdminClient, func(), error) {
+	if host != "" {
+		client := NewAdminClient(host, token)
+		if waitForAdmin(client, timeout) == nil {
+			return client, func() {}, nil
+		}
+	}
+
+	address, stop, err := ForwardAdminPort(appName, 0, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := NewAdminClient(address, token)
+	err = waitForAdmin(client, timeout)
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	return client, stop, nil
+}
+
+// ForwardAdminPort forwards a free local port to the admin API of one
+// instance of appName with cf ssh, and returns the local address once it
+// accepts connections. The returned function stops the forwarding.
+func ForwardAdminPort(appName string, instance int, timeout time.Duration) (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	address := listener.Addr().String()
+	localPort := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	session := cf.Cf("ssh", appName,
+		"-i", strconv.Itoa(instance),
+		"-N",
+		"-L", fmt.Sprintf("%d:localhost:%d", localPort, ADMIN_PORT),
+	)
+	stop := func() {
+		session.Interrupt().Wait(timeout)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", address, PORT_FORWARD_POLL_INTERVAL)
+		if err == nil {
+			conn.Close()
+			return address, stop, nil
+		}
+		if session.ExitCode() != -1 || time.Now().After(deadline) {
+			stop()
+			return "", nil, fmt.Errorf("forwarding %s to %s/%d port %d: %w", address, appName, instance, ADMIN_PORT, err)
+		}
+		time.Sleep(PORT_FORWARD_POLL_INTERVAL)
+	}
+}
+
+func waitForAdmin(client *AdminClient, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := client.State()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(PORT_FORWARD_POLL_INTERVAL)
+	}
+}
@@ -0,0 +1,37 @@
+package receiver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Identity is the response tcp-receiver writes for each message when run
+// with --format=json. InstanceIndex is nil when the receiver runs outside
+// Cloud Foundry, where CF_INSTANCE_INDEX is not set.
+type Identity struct {
+	ServerID      string `json:"server_id"`
+	ListenAddress string `json:"listen_address"`
+	InstanceIndex *int   `json:"instance_index"`
+	InstanceGUID  string `json:"instance_guid"`
+	InstanceIP    string `json:"instance_ip"`
+	ClientAddress string `json:"client_address"`
+	Bytes         int    `json:"bytes"`
+	Message       string `json:"message"`
+	SNI           string `json:"sni"`
+	ALPN          string `json:"alpn"`
+	CertSHA256    string `json:"cert_sha256"`
+}
+
+// DecodeIdentity parses a single JSON response from tcp-receiver.
+func DecodeIdentity(response []byte) (Identity, error) {
+	var identity Identity
+	err := json.Unmarshal(response, &identity)
+	if err != nil {
+		return Identity{}, fmt.Errorf("decoding receiver response %q: %w", response, err)
+	}
+	if identity.ServerID == "" {
+		return Identity{}, errors.New("receiver response has no server_id")
+	}
+	return identity, nil
+}
@@ -0,0 +1,45 @@
+package receiver_test
+
+import (
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/receiver"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecodeIdentity", func() {
+	It("decodes every field of a JSON response", func() {
+		index := 1
+		identity, err := receiver.DecodeIdentity([]byte(`{"server_id":"server:1","listen_address":"0.0.0.0:3333","instance_index":1,"instance_guid":"6f3e2a1c-0d4b-4a8e-9b7c-2f1e0a9d8c7b","instance_ip":"10.0.16.5","client_address":"10.0.1.4:51234","bytes":12,"message":"hello: world","sni":"app.example.com","alpn":"h2","cert_sha256":"abc123"}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity).To(Equal(receiver.Identity{
+			ServerID:      "server:1",
+			ListenAddress: "0.0.0.0:3333",
+			InstanceIndex: &index,
+			InstanceGUID:  "6f3e2a1c-0d4b-4a8e-9b7c-2f1e0a9d8c7b",
+			InstanceIP:    "10.0.16.5",
+			ClientAddress: "10.0.1.4:51234",
+			Bytes:         12,
+			Message:       "hello: world",
+			SNI:           "app.example.com",
+			ALPN:          "h2",
+			CertSHA256:    "abc123",
+		}))
+	})
+
+	It("leaves the instance index unset when the receiver reports none", func() {
+		identity, err := receiver.DecodeIdentity([]byte(`{"server_id":"server:1","instance_index":null}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity.InstanceIndex).To(BeNil())
+	})
+
+	It("fails on a text response", func() {
+		_, err := receiver.DecodeIdentity([]byte("server1:hello"))
+		Expect(err).To(MatchError(ContainSubstring(`decoding receiver response "server1:hello"`)))
+	})
+
+	It("fails on a response without a server id", func() {
+		_, err := receiver.DecodeIdentity([]byte(`{"message":"hello"}`))
+		Expect(err).To(MatchError("receiver response has no server_id"))
+	})
+})
@@ -0,0 +1,211 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+type v3Resources struct {
+	Resources []struct {
+		GUID string `json:"guid"`
+		Path string `json:"path"`
+	} `json:"resources"`
+}
+
+// cfCurl makes a Cloud Controller API request as the targeted user and
+// returns the response body.
+func cfCurl(timeout time.Duration, path string, args ...string) []byte {
+	session := cf.Cf(append([]string{"curl", "--fail", path}, args...)...)
+	Eventually(session, timeout).Should(gexec.Exit(0), fmt.Sprintf("cf curl %s", path))
+	return session.Out.Contents()
+}
+
+// CCError is an error the Cloud Controller v3 API responded with.
+type CCError struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+func (e CCError) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Title, e.Code, e.Detail)
+}
+
+// writeJSONFile writes data as JSON to a temporary file, for cf curl -d,
+// and returns its path. The caller removes it.
+func writeJSONFile(data interface{}) string {
+	contents, err := json.Marshal(data)
+	Expect(err).NotTo(HaveOccurred())
+
+	f, err := os.CreateTemp("", "cf-curl-json")
+	Expect(err).NotTo(HaveOccurred())
+	_, err = f.Write(contents)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(f.Close()).To(Succeed())
+	return f.Name()
+}
+
+func domainGUID(domain string, timeout time.Duration) string {
+	var domains v3Resources
+	body := cfCurl(timeout, "/v3/domains?names="+url.QueryEscape(domain))
+	Expect(json.Unmarshal(body, &domains)).To(Succeed(), string(body))
+	Expect(domains.Resources).To(HaveLen(1), fmt.Sprintf("domain %s", domain))
+	return domains.Resources[0].GUID
+}
+
+// SpaceGUID returns the guid of the space in the targeted org.
+func SpaceGUID(spaceName string, timeout time.Duration) string {
+	session := cf.Cf("space", spaceName, "--guid")
+	Eventually(session, timeout).Should(gexec.Exit(0))
+	return strings.TrimSpace(string(session.Out.Contents()))
+}
+
+// CreateRoute creates a route on domain in the space through the Cloud
+// Controller v3 API, with per-route options such as loadbalancing. host is
+// left out when empty, and routes on TCP domains get a random port. It
+// returns the guid of the route, or the first error the Cloud Controller
+// responded with.
+func CreateRoute(spaceGUID, domain, host string, options map[string]string, timeout time.Duration) (string, error) {
+	request := map[string]interface{}{
+		"relationships": map[string]interface{}{
+			"space":  map[string]interface{}{"data": map[string]string{"guid": spaceGUID}},
+			"domain": map[string]interface{}{"data": map[string]string{"guid": domainGUID(domain, timeout)}},
+		},
+	}
+	if options != nil {
+		request["options"] = options
+	}
+	if host != "" {
+		request["host"] = host
+	}
+	path := writeJSONFile(request)
+	defer os.Remove(path)
+
+	// Without --fail, cf curl prints the errors the Cloud Controller
+	// responds with.
+	session := cf.Cf("curl", "/v3/routes", "-X", "POST", "-d", "@"+path)
+	Eventually(session, timeout).Should(gexec.Exit(0))
+
+	var response struct {
+		GUID   string    `json:"guid"`
+		Errors []CCError `json:"errors"`
+	}
+	body := session.Out.Contents()
+	Expect(json.Unmarshal(body, &response)).To(Succeed(), string(body))
+	if len(response.Errors) > 0 {
+		return "", response.Errors[0]
+	}
+	Expect(response.GUID).NotTo(BeEmpty(), string(body))
+	return response.GUID, nil
+}
+
+// RouteOptions returns the per-route options the Cloud Controller has for
+// the route.
+func RouteOptions(routeGUID string, timeout time.Duration) map[string]string {
+	var route struct {
+		Options map[string]string `json:"options"`
+	}
+	body := cfCurl(timeout, "/v3/routes/"+routeGUID)
+	Expect(json.Unmarshal(body, &route)).To(Succeed(), string(body))
+	return route.Options
+}
+
+// RoutePort returns the port the Cloud Controller gave a TCP route.
+func RoutePort(routeGUID string, timeout time.Duration) uint16 {
+	var route struct {
+		Port *uint16 `json:"port"`
+	}
+	body := cfCurl(timeout, "/v3/routes/"+routeGUID)
+	Expect(json.Unmarshal(body, &route)).To(Succeed(), string(body))
+	Expect(route.Port).NotTo(BeNil(), fmt.Sprintf("route %s has no port", routeGUID))
+	return *route.Port
+}
+
+// RouteGUID returns the guid of the route for host and path on domain, where
+// path is empty for routes without a context path.
+func RouteGUID(host, domain, path string, timeout time.Duration) string {
+	query := url.Values{
+		"hosts":        {host},
+		"domain_guids": {domainGUID(domain, timeout)},
+	}
+	var routes v3Resources
+	body := cfCurl(timeout, "/v3/routes?"+query.Encode())
+	Expect(json.Unmarshal(body, &routes)).To(Succeed(), string(body))
+	guid := ""
+	for _, route := range routes.Resources {
+		if route.Path == path {
+			guid = route.GUID
+		}
+	}
+	Expect(guid).NotTo(BeEmpty(), fmt.Sprintf("no route %s.%s%s in %s", host, domain, path, body))
+	return guid
+}
+
+// UpdateRouteOptions sets per-route options, such as loadbalancing, through
+// the Cloud Controller v3 API. Options not given are left unchanged.
+func UpdateRouteOptions(routeGUID string, options map[string]string, timeout time.Duration) {
+	path := writeJSONFile(map[string]interface{}{"options": options})
+	defer os.Remove(path)
+
+	cfCurl(timeout, "/v3/routes/"+routeGUID, "-X", "PATCH", "-d", "@"+path)
+}
+
+// AppGUID returns the guid of the app in the targeted space.
+func AppGUID(appName string, timeout time.Duration) string {
+	session := cf.Cf("app", appName, "--guid")
+	Eventually(session, timeout).Should(gexec.Exit(0))
+	return strings.TrimSpace(string(session.Out.Contents()))
+}
+
+// InstanceAddress is where the routers reach an app instance, according to
+// its process stats.
+type InstanceAddress struct {
+	Index int
+	Host  string
+	Port  uint16
+	// Routable is false while the instance fails its readiness health
+	// check.
+	Routable bool
+}
+
+// InstanceAddresses returns the host and external port of every running
+// instance of the app's web process.
+func InstanceAddresses(appGUID string, timeout time.Duration) []InstanceAddress {
+	var stats struct {
+		Resources []struct {
+			Index         int    `json:"index"`
+			State         string `json:"state"`
+			Host          string `json:"host"`
+			Routable      *bool  `json:"routable"`
+			InstancePorts []struct {
+				External uint16 `json:"external"`
+			} `json:"instance_ports"`
+		} `json:"resources"`
+	}
+	body := cfCurl(timeout, "/v3/apps/"+appGUID+"/processes/web/stats")
+	Expect(json.Unmarshal(body, &stats)).To(Succeed(), string(body))
+
+	var instances []InstanceAddress
+	for _, instance := range stats.Resources {
+		if instance.State != "RUNNING" || instance.Host == "" || len(instance.InstancePorts) == 0 {
+			continue
+		}
+		instances = append(instances, InstanceAddress{
+			Index: instance.Index,
+			Host:  instance.Host,
+			Port:  instance.InstancePorts[0].External,
+			// Cloud Controllers without readiness checks leave it out.
+			Routable: instance.Routable == nil || *instance.Routable,
+		})
+	}
+	return instances
+}
@@ -0,0 +1,3 @@
+package tcpclient
+
+var ErrorKey = errorKey
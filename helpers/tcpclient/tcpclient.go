@@ -0,0 +1,481 @@
+package tcpclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	DEFAULT_CONNECT_TIMEOUT  = 5 * time.Second
+	DEFAULT_RW_TIMEOUT       = 2 * time.Second
+	DEFAULT_DIAL_CONCURRENCY = 100
+	CONN_TYPE                = "tcp"
+	BUFFER_SIZE              = 1024
+)
+
+// Exchange writes message to conn and returns the first response read back.
+func Exchange(conn net.Conn, message []byte, timeout time.Duration) ([]byte, error) {
+	err := conn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Write(message)
+	if err != nil {
+		return nil, err
+	}
+
+	buff := make([]byte, BUFFER_SIZE)
+	n, err := conn.Read(buff)
+	if err != nil {
+		return nil, err
+	}
+
+	return buff[:n], nil
+}
+
+// MeasureIdleClose stays silent on conn until the peer closes it, and returns
+// how long that took. It fails if the connection is still open after max, or
+// if the peer sends anything.
+func MeasureIdleClose(conn net.Conn, max time.Duration) (time.Duration, error) {
+	start := time.Now()
+	err := conn.SetReadDeadline(start.Add(max))
+	if err != nil {
+		return 0, err
+	}
+
+	buff := make([]byte, BUFFER_SIZE)
+	n, err := conn.Read(buff)
+	elapsed := time.Since(start)
+	switch {
+	case err == nil:
+		return elapsed, fmt.Errorf("received %q on an idle connection", buff[:n])
+	case Classify(err) == TimedOut:
+		return elapsed, fmt.Errorf("connection still open after %s idle", max)
+	case errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET):
+		return elapsed, nil
+	default:
+		return elapsed, err
+	}
+}
+
+// KeepAlive exchanges a message on conn every interval until duration has
+// passed, and returns the first failure.
+func KeepAlive(conn net.Conn, interval, duration, timeout time.Duration) error {
+	deadline := time.Now().Add(duration)
+	for i := 0; time.Now().Before(deadline); i++ {
+		_, err := Exchange(conn, []byte(fmt.Sprintf("keepalive %d", i)), timeout)
+		if err != nil {
+			return fmt.Errorf("keepalive %d after %s: %w", i, duration-time.Until(deadline), err)
+		}
+		time.Sleep(interval)
+	}
+	return nil
+}
+
+// DialTLS connects to address and completes a TLS handshake within timeout.
+func DialTLS(address string, config *tls.Config, timeout time.Duration) (*tls.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.Dial(CONN_TYPE, address)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, config)
+	err = tlsConn.SetDeadline(time.Now().Add(timeout))
+	if err == nil {
+		err = tlsConn.Handshake()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, tlsConn.SetDeadline(time.Time{})
+}
+
+// VerifySelfSigned checks that cert is a valid self-signed certificate for
+// serverName.
+func VerifySelfSigned(cert *x509.Certificate, serverName string) error {
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	_, err := cert.Verify(x509.VerifyOptions{DNSName: serverName, Roots: roots})
+	return err
+}
+
+// Fingerprint returns the hex encoded SHA-256 of the certificate, in the form
+// receivers report it.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// ReportedField returns the value of a name=value field that a receiver
+// appended to its response, or "" if the field is absent.
+func ReportedField(response []byte, name string) string {
+	for _, field := range strings.Fields(string(response)) {
+		if strings.HasPrefix(field, name+"=") {
+			return strings.TrimPrefix(field, name+"=")
+		}
+	}
+	return ""
+}
+
+type Outcome int
+
+const (
+	Succeeded Outcome = iota
+	Refused
+	TimedOut
+	Failed
+)
+
+// Classify maps a dial or exchange error onto the outcome it is reported as.
+func Classify(err error) Outcome {
+	if err == nil {
+		return Succeeded
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return Refused
+	}
+
+	var netErr net.Error
+	if errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return TimedOut
+	}
+
+	return Failed
+}
+
+// errorKey drops the connection addresses from err so that the same failure
+// on different connections is counted together.
+func errorKey(err error) string {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return fmt.Sprintf("%s: %s", opErr.Op, opErr.Err)
+	}
+	return err.Error()
+}
+
+type ScaleOptions struct {
+	Connections     int
+	DialConcurrency int
+	HoldDuration    time.Duration
+	ConnectTimeout  time.Duration
+	RWTimeout       time.Duration
+}
+
+type ScaleResult struct {
+	Address   string
+	Attempted int
+	Succeeded int
+	Refused   int
+	TimedOut  int
+	Failed    int
+	// Dropped counts the successful connections that no longer answered
+	// once the hold was over.
+	Dropped          int
+	Errors           map[string]int
+	ConnectLatencies []time.Duration
+}
+
+// Percentile returns the p-th percentile (0-100) of the successful connect latencies.
+func (r ScaleResult) Percentile(p float64) time.Duration {
+	if len(r.ConnectLatencies) == 0 {
+		return 0
+	}
+
+	latencies := make([]time.Duration, len(r.ConnectLatencies))
+	copy(latencies, r.ConnectLatencies)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	rank := int(p/100*float64(len(latencies))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(latencies) {
+		rank = len(latencies) - 1
+	}
+
+	return latencies[rank]
+}
+
+func (r ScaleResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "address=%s attempted=%d succeeded=%d refused=%d timed_out=%d failed=%d dropped=%d",
+		r.Address, r.Attempted, r.Succeeded, r.Refused, r.TimedOut, r.Failed, r.Dropped)
+	fmt.Fprintf(&b, " connect_latency_p50=%s p90=%s p99=%s max=%s",
+		r.Percentile(50), r.Percentile(90), r.Percentile(99), r.Percentile(100))
+
+	errs := make([]string, 0, len(r.Errors))
+	for msg, count := range r.Errors {
+		errs = append(errs, fmt.Sprintf("%dx %s", count, msg))
+	}
+	sort.Strings(errs)
+	for _, e := range errs {
+		fmt.Fprintf(&b, "\n  %s", e)
+	}
+
+	return b.String()
+}
+
+// RunScale opens opts.Connections concurrent connections to address, exchanges
+// a message on each and holds them all open for opts.HoldDuration. It then
+// exchanges another message on each held connection, counting the ones that
+// fail as dropped, before closing them.
+func RunScale(address string, opts ScaleOptions) ScaleResult {
+	if opts.DialConcurrency <= 0 {
+		opts.DialConcurrency = DEFAULT_DIAL_CONCURRENCY
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DEFAULT_CONNECT_TIMEOUT
+	}
+	if opts.RWTimeout <= 0 {
+		opts.RWTimeout = DEFAULT_RW_TIMEOUT
+	}
+
+	result := ScaleResult{
+		Address:   address,
+		Attempted: opts.Connections,
+		Errors:    map[string]int{},
+	}
+
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+		wg    sync.WaitGroup
+	)
+	dialSlots := make(chan struct{}, opts.DialConcurrency)
+
+	record := func(err error, latency time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch Classify(err) {
+		case Succeeded:
+			result.Succeeded++
+			result.ConnectLatencies = append(result.ConnectLatencies, latency)
+			return
+		case Refused:
+			result.Refused++
+		case TimedOut:
+			result.TimedOut++
+		default:
+			result.Failed++
+		}
+		result.Errors[errorKey(err)]++
+	}
+
+	for i := 0; i < opts.Connections; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			dialSlots <- struct{}{}
+			start := time.Now()
+			conn, err := net.DialTimeout(CONN_TYPE, address, opts.ConnectTimeout)
+			latency := time.Since(start)
+			<-dialSlots
+			if err != nil {
+				record(err, latency)
+				return
+			}
+
+			message := []byte(fmt.Sprintf("connection %d", i))
+			_, err = Exchange(conn, message, opts.RWTimeout)
+			if err != nil {
+				conn.Close()
+				record(err, latency)
+				return
+			}
+
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+			record(nil, latency)
+		}(i)
+	}
+	wg.Wait()
+
+	time.Sleep(opts.HoldDuration)
+
+	for i, conn := range conns {
+		wg.Add(1)
+		go func(i int, conn net.Conn) {
+			defer wg.Done()
+			defer conn.Close()
+
+			_, err := Exchange(conn, []byte(fmt.Sprintf("held connection %d", i)), opts.RWTimeout)
+			if err != nil {
+				mu.Lock()
+				result.Dropped++
+				result.Errors["after hold: "+errorKey(err)]++
+				mu.Unlock()
+			}
+		}(i, conn)
+	}
+	wg.Wait()
+
+	return result
+}
+
+type TrafficOptions struct {
+	Workers        int
+	ConnectTimeout time.Duration
+	// RWTimeout bounds each read and write, so it must exceed any response
+	// latency configured on the receiver.
+	RWTimeout time.Duration
+}
+
+// TrafficResult counts the exchanges made by RunGreetedTraffic. An exchange
+// is not accepted when the greeting never arrives, and truncated when the
+// greeting arrived but the full response did not.
+type TrafficResult struct {
+	Address     string
+	Completed   int
+	NotAccepted int
+	Truncated   int
+	// Truncations describes each truncated exchange.
+	Truncations []string
+}
+
+func (r TrafficResult) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "address=%s completed=%d not_accepted=%d truncated=%d",
+		r.Address, r.Completed, r.NotAccepted, r.Truncated)
+	for _, t := range r.Truncations {
+		fmt.Fprintf(&b, "\n  %s", t)
+	}
+	return b.String()
+}
+
+// ReadGreeting reads the greeting line a server-first backend sends on
+// accept, without writing anything to conn first. Each read must complete
+// within timeout.
+func ReadGreeting(conn net.Conn, timeout time.Duration) ([]byte, error) {
+	var greeting []byte
+	buff := make([]byte, BUFFER_SIZE)
+	for !strings.HasSuffix(string(greeting), "\r\n") {
+		err := conn.SetReadDeadline(time.Now().Add(timeout))
+		if err != nil {
+			return greeting, err
+		}
+		n, err := conn.Read(buff)
+		greeting = append(greeting, buff[:n]...)
+		if err != nil {
+			return greeting, err
+		}
+	}
+	return greeting, nil
+}
+
+// GreetedExchange makes a single exchange with a receiver in greeting mode:
+// it waits for the greeting line, sends message and reads until the
+// identifying response to message is complete. It returns the outcome as
+// reported by RunGreetedTraffic along with the partial response, if any.
+func GreetedExchange(address string, message string, opts TrafficOptions) (accepted bool, response []byte, err error) {
+	conn, err := net.DialTimeout(CONN_TYPE, address, opts.ConnectTimeout)
+	if err != nil {
+		return false, nil, err
+	}
+	defer conn.Close()
+
+	_, err = ReadGreeting(conn, opts.RWTimeout)
+	if err != nil {
+		return false, nil, err
+	}
+
+	buff := make([]byte, BUFFER_SIZE)
+	err = conn.SetWriteDeadline(time.Now().Add(opts.RWTimeout))
+	if err != nil {
+		return true, nil, err
+	}
+	_, err = conn.Write([]byte(message))
+	if err != nil {
+		return true, nil, err
+	}
+
+	for !strings.HasSuffix(string(response), ":"+message) {
+		err = conn.SetReadDeadline(time.Now().Add(opts.RWTimeout))
+		if err != nil {
+			return true, response, err
+		}
+		n, err := conn.Read(buff)
+		if err != nil {
+			return true, response, err
+		}
+		response = append(response, buff[:n]...)
+	}
+	return true, response, nil
+}
+
+// RunGreetedTraffic keeps opts.Workers exchanges in flight against a receiver
+// in greeting mode, each on its own connection, until stop is closed.
+func RunGreetedTraffic(address string, opts TrafficOptions, stop <-chan struct{}) TrafficResult {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DEFAULT_CONNECT_TIMEOUT
+	}
+	if opts.RWTimeout <= 0 {
+		opts.RWTimeout = DEFAULT_RW_TIMEOUT
+	}
+
+	result := TrafficResult{Address: address}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				message := fmt.Sprintf("worker %d exchange %d", w, i)
+				accepted, response, err := GreetedExchange(address, message, opts)
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					result.Completed++
+				case !accepted:
+					result.NotAccepted++
+				default:
+					result.Truncated++
+					result.Truncations = append(result.Truncations,
+						fmt.Sprintf("%s at %s: received %q: %s", message, time.Now().Format(time.RFC3339Nano), response, errorKey(err)))
+				}
+				mu.Unlock()
+
+				if err != nil {
+					// Back off while the backend is unavailable.
+					time.Sleep(100 * time.Millisecond)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return result
+}
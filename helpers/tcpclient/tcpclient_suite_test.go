@@ -0,0 +1,13 @@
+package tcpclient_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTcpclient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tcpclient Suite")
+}
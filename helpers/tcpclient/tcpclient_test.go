@@ -0,0 +1,256 @@
+package tcpclient_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// serve accepts connections on a loopback listener and hands each to
+// handle, until the spec ends. It returns the listener's address.
+func serve(handle func(net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(listener.Close)
+
+	go func() {
+		defer GinkgoRecover()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func dial(address string) net.Conn {
+	conn, err := net.Dial("tcp", address)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(conn.Close)
+	return conn
+}
+
+// respond answers each message with "server1:<message>", the way
+// tcp-receiver does.
+func respond(conn net.Conn) {
+	buff := make([]byte, tcpclient.BUFFER_SIZE)
+	for {
+		n, err := conn.Read(buff)
+		if err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte("server1:" + string(buff[:n])))
+	}
+}
+
+// hold keeps conn open and silent until the peer closes it.
+func hold(conn net.Conn) {
+	_, _ = io.Copy(io.Discard, conn)
+}
+
+var _ = Describe("ScaleResult", func() {
+	ms := func(values ...int) []time.Duration {
+		latencies := make([]time.Duration, len(values))
+		for i, v := range values {
+			latencies[i] = time.Duration(v) * time.Millisecond
+		}
+		return latencies
+	}
+
+	DescribeTable("Percentile",
+		func(latencies []time.Duration, p float64, expected time.Duration) {
+			result := tcpclient.ScaleResult{ConnectLatencies: latencies}
+			Expect(result.Percentile(p)).To(Equal(expected))
+		},
+		Entry("no latencies", nil, 50.0, time.Duration(0)),
+		Entry("a single latency", ms(7), 99.0, 7*time.Millisecond),
+		Entry("the median of unsorted latencies", ms(30, 10, 40, 20), 50.0, 20*time.Millisecond),
+		Entry("the lowest for p0", ms(30, 10, 40, 20), 0.0, 10*time.Millisecond),
+		Entry("the highest for p100", ms(30, 10, 40, 20), 100.0, 40*time.Millisecond),
+		Entry("p90 of ten latencies", ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 90.0, 9*time.Millisecond),
+		Entry("p99 of ten latencies", ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 99.0, 10*time.Millisecond),
+	)
+
+	It("leaves the recorded latencies in order", func() {
+		result := tcpclient.ScaleResult{ConnectLatencies: ms(30, 10, 20)}
+		result.Percentile(50)
+		Expect(result.ConnectLatencies).To(Equal(ms(30, 10, 20)))
+	})
+})
+
+var _ = DescribeTable("Classify",
+	func(err error, expected tcpclient.Outcome) {
+		Expect(tcpclient.Classify(err)).To(Equal(expected))
+	},
+	Entry("no error", nil, tcpclient.Succeeded),
+	Entry("a refused dial", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, tcpclient.Refused),
+	Entry("a deadline", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, tcpclient.TimedOut),
+	Entry("a timeout net.Error", &net.DNSError{Err: "i/o timeout", IsTimeout: true}, tcpclient.TimedOut),
+	Entry("a reset", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, tcpclient.Failed),
+	Entry("EOF", io.EOF, tcpclient.Failed),
+)
+
+var _ = DescribeTable("errorKey",
+	func(err error, expected string) {
+		Expect(tcpclient.ErrorKey(err)).To(Equal(expected))
+	},
+	Entry("drops the addresses of a net.OpError",
+		&net.OpError{Op: "dial", Net: "tcp", Source: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 51234}, Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1024}, Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+		"dial: connect: connection refused"),
+	Entry("finds a wrapped net.OpError",
+		fmt.Errorf("keepalive 3: %w", &net.OpError{Op: "read", Net: "tcp", Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1024}, Err: os.ErrDeadlineExceeded}),
+		"read: i/o timeout"),
+	Entry("keeps other errors as they are", errors.New("EOF"), "EOF"),
+)
+
+var _ = DescribeTable("ReportedField",
+	func(response, name, expected string) {
+		Expect(tcpclient.ReportedField([]byte(response), name)).To(Equal(expected))
+	},
+	Entry("a field", "server1:hello sni=app.example.com alpn=h2", "sni", "app.example.com"),
+	Entry("the last field", "server1:hello sni=app.example.com alpn=h2", "alpn", "h2"),
+	Entry("an absent field", "server1:hello alpn=h2", "sni", ""),
+	Entry("an empty field", "server1:hello sni= alpn=h2", "sni", ""),
+	Entry("a field whose name another field starts with", "server1:hello snihost=other sni=app.example.com", "sni", "app.example.com"),
+)
+
+var _ = Describe("over loopback", func() {
+	Describe("Exchange", func() {
+		It("returns the response to the message", func() {
+			conn := dial(serve(respond))
+			Expect(tcpclient.Exchange(conn, []byte("hello"), time.Second)).To(BeEquivalentTo("server1:hello"))
+			Expect(tcpclient.Exchange(conn, []byte("again"), time.Second)).To(BeEquivalentTo("server1:again"))
+		})
+
+		It("times out when there is no response", func() {
+			conn := dial(serve(hold))
+			_, err := tcpclient.Exchange(conn, []byte("hello"), 100*time.Millisecond)
+			Expect(tcpclient.Classify(err)).To(Equal(tcpclient.TimedOut))
+		})
+	})
+
+	Describe("MeasureIdleClose", func() {
+		It("measures how long the peer takes to close an idle connection", func() {
+			conn := dial(serve(func(conn net.Conn) {
+				time.Sleep(200 * time.Millisecond)
+			}))
+			elapsed, err := tcpclient.MeasureIdleClose(conn, 5*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(elapsed).To(BeNumerically(">=", 200*time.Millisecond))
+			Expect(elapsed).To(BeNumerically("<", 5*time.Second))
+		})
+
+		It("fails when the connection is still open after max", func() {
+			conn := dial(serve(hold))
+			_, err := tcpclient.MeasureIdleClose(conn, 100*time.Millisecond)
+			Expect(err).To(MatchError("connection still open after 100ms idle"))
+		})
+
+		It("fails when the peer sends anything", func() {
+			conn := dial(serve(func(conn net.Conn) {
+				_, _ = conn.Write([]byte("hi"))
+				hold(conn)
+			}))
+			_, err := tcpclient.MeasureIdleClose(conn, 5*time.Second)
+			Expect(err).To(MatchError(`received "hi" on an idle connection`))
+		})
+	})
+
+	Describe("GreetedExchange", func() {
+		opts := tcpclient.TrafficOptions{ConnectTimeout: time.Second, RWTimeout: time.Second}
+
+		greet := func(conn net.Conn) {
+			_, _ = conn.Write([]byte("220 server1 ready\r\n"))
+		}
+
+		It("reads the greeting, then the whole response to the message", func() {
+			address := serve(func(conn net.Conn) {
+				greet(conn)
+				respond(conn)
+			})
+			accepted, response, err := tcpclient.GreetedExchange(address, "hello", opts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accepted).To(BeTrue())
+			Expect(response).To(BeEquivalentTo("server1:hello"))
+		})
+
+		It("reports a connection closed before the greeting as not accepted", func() {
+			address := serve(func(conn net.Conn) {})
+			accepted, _, err := tcpclient.GreetedExchange(address, "hello", opts)
+			Expect(err).To(HaveOccurred())
+			Expect(accepted).To(BeFalse())
+		})
+
+		It("returns the partial response of a connection closed mid-response", func() {
+			address := serve(func(conn net.Conn) {
+				greet(conn)
+				buff := make([]byte, tcpclient.BUFFER_SIZE)
+				_, _ = conn.Read(buff)
+				_, _ = conn.Write([]byte("server1:hel"))
+			})
+			accepted, response, err := tcpclient.GreetedExchange(address, "hello", opts)
+			Expect(err).To(MatchError(io.EOF))
+			Expect(accepted).To(BeTrue())
+			Expect(response).To(BeEquivalentTo("server1:hel"))
+		})
+	})
+
+	Describe("RunScale", func() {
+		opts := tcpclient.ScaleOptions{
+			Connections:    20,
+			HoldDuration:   100 * time.Millisecond,
+			ConnectTimeout: time.Second,
+			RWTimeout:      time.Second,
+		}
+
+		It("holds every connection open and exchanges on it again after the hold", func() {
+			result := tcpclient.RunScale(serve(respond), opts)
+			Expect(result.Attempted).To(Equal(20))
+			Expect(result.Succeeded).To(Equal(20), result.String())
+			Expect(result.Dropped).To(BeZero(), result.String())
+			Expect(result.ConnectLatencies).To(HaveLen(20))
+		})
+
+		It("counts refused connections", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			address := listener.Addr().String()
+			Expect(listener.Close()).To(Succeed())
+
+			result := tcpclient.RunScale(address, opts)
+			Expect(result.Succeeded).To(BeZero())
+			Expect(result.Refused).To(Equal(20), result.String())
+			Expect(result.Errors).To(Equal(map[string]int{"dial: connect: connection refused": 20}))
+		})
+
+		It("counts connections closed during the hold as dropped", func() {
+			address := serve(func(conn net.Conn) {
+				buff := make([]byte, tcpclient.BUFFER_SIZE)
+				n, err := conn.Read(buff)
+				if err == nil {
+					_, _ = conn.Write([]byte("server1:" + string(buff[:n])))
+				}
+			})
+
+			result := tcpclient.RunScale(address, opts)
+			Expect(result.Succeeded).To(Equal(20), result.String())
+			Expect(result.Dropped).To(Equal(20), result.String())
+			Expect(result.String()).To(ContainSubstring("dropped=20"))
+		})
+	})
+})
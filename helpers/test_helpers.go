@@ -25,11 +25,70 @@ type RoutingConfig struct {
 	*config.Config
 	RoutingApiUrl     string       `json:"-"` //"-" is used for ignoring field
 	Addresses         []string     `json:"addresses"`
+	AddressFamilies   []string     `json:"address_families"`
 	OAuth             *OAuthConfig `json:"oauth"`
 	IncludeHttpRoutes bool         `json:"include_http_routes"`
 	TcpAppDomain      string       `json:"tcp_apps_domain"`
 	LBConfigured      bool         `json:"lb_configured"`
 	TCPRouterGroup    string       `json:"tcp_router_group"`
+
+	IncludeScaleTests     bool `json:"include_scale_tests"`
+	ScaleTestConnections  int  `json:"scale_test_connections"`
+	ScaleTestHoldDuration int  `json:"scale_test_hold_duration"`
+
+	IncludeProxyProtocolTests bool   `json:"include_proxy_protocol_tests"`
+	EgressIP                  string `json:"egress_ip"`
+
+	ExpectedIdleTimeout int `json:"expected_idle_timeout"`
+
+	MaxGreetingLatencyMs int `json:"max_greeting_latency_ms"`
+
+	IncludeHttp2Routes bool `json:"include_http2_routes"`
+
+	GorouterAddresses    []string `json:"gorouter_addresses"`
+	IncludeRouteServices bool     `json:"include_route_services"`
+	// TracingHeaders lists the trace context formats Gorouter is expected
+	// to generate and propagate: TRACING_B3 and/or TRACING_W3C.
+	TracingHeaders []string `json:"tracing_headers"`
+
+	// MtlsDomain is a shared domain on which Gorouter requires client
+	// certificates, and forwards them to apps in MtlsXfccFormat.
+	MtlsDomain         string `json:"mtls_domain"`
+	MtlsClientCertPath string `json:"mtls_client_cert_path"`
+	MtlsClientKeyPath  string `json:"mtls_client_key_path"`
+	MtlsXfccFormat     string `json:"mtls_xfcc_format"`
+
+	IncludeRetryTests   bool `json:"include_retry_tests"`
+	EndpointPruneWindow int  `json:"endpoint_prune_window"`
+
+	MaxHeaderKB int `json:"max_header_kb"`
+	LargeBodyMB int `json:"large_body_mb"`
+
+	// IncludeReadinessTests needs Cloud Controller and Diego support for
+	// readiness health checks.
+	IncludeReadinessTests bool `json:"include_readiness_tests"`
+
+	UsePrebuiltAssets   bool   `json:"use_prebuilt_assets"`
+	BinaryBuildpackName string `json:"binary_buildpack_name"`
+}
+
+const (
+	TRACING_B3  = "b3"
+	TRACING_W3C = "w3c"
+
+	XFCC_RAW   = "raw"
+	XFCC_ENVOY = "envoy"
+)
+
+// TracesWith reports whether Gorouter is expected to generate and propagate
+// trace context in format.
+func (c RoutingConfig) TracesWith(format string) bool {
+	for _, f := range c.TracingHeaders {
+		if f == format {
+			return true
+		}
+	}
+	return false
 }
 
 type OAuthConfig struct {
@@ -48,11 +107,62 @@ func loadDefaultTimeout(conf *RoutingConfig) {
 		conf.CfPushTimeout = 120
 	}
 }
+
+func loadScaleTestDefaults(conf *RoutingConfig) {
+	if conf.ScaleTestConnections <= 0 {
+		conf.ScaleTestConnections = 2000
+	}
+
+	if conf.ScaleTestHoldDuration <= 0 {
+		conf.ScaleTestHoldDuration = 10
+	}
+}
+
+func loadServerFirstDefaults(conf *RoutingConfig) {
+	if conf.MaxGreetingLatencyMs <= 0 {
+		conf.MaxGreetingLatencyMs = 1000
+	}
+}
+
+func loadPrebuiltAssetsDefaults(conf *RoutingConfig) {
+	if conf.BinaryBuildpackName == "" {
+		conf.BinaryBuildpackName = "binary_buildpack"
+	}
+}
+
+func loadMtlsDefaults(conf *RoutingConfig) {
+	if conf.MtlsXfccFormat == "" {
+		conf.MtlsXfccFormat = XFCC_RAW
+	}
+}
+
+func loadRetryDefaults(conf *RoutingConfig) {
+	if conf.EndpointPruneWindow <= 0 {
+		conf.EndpointPruneWindow = 30
+	}
+}
+
+func loadTransferDefaults(conf *RoutingConfig) {
+	if conf.MaxHeaderKB <= 0 {
+		conf.MaxHeaderKB = 1024
+	}
+
+	if conf.LargeBodyMB <= 0 {
+		conf.LargeBodyMB = 20
+	}
+}
+
 func LoadConfig() RoutingConfig {
 	loadedConfig := loadConfigJsonFromPath()
 
 	loadedConfig.Config = config.LoadConfig()
 	loadDefaultTimeout(&loadedConfig)
+	loadScaleTestDefaults(&loadedConfig)
+	loadServerFirstDefaults(&loadedConfig)
+	loadPrebuiltAssetsDefaults(&loadedConfig)
+	loadMtlsDefaults(&loadedConfig)
+	loadRetryDefaults(&loadedConfig)
+	loadTransferDefaults(&loadedConfig)
 
 	if loadedConfig.OAuth == nil {
 		panic("missing configuration oauth")
@@ -74,6 +184,20 @@ func LoadConfig() RoutingConfig {
 		panic("missing configuration tcp_router_group")
 	}
 
+	for _, format := range loadedConfig.TracingHeaders {
+		if format != TRACING_B3 && format != TRACING_W3C {
+			panic(fmt.Sprintf("unknown tracing_headers entry %q, expected %q or %q", format, TRACING_B3, TRACING_W3C))
+		}
+	}
+
+	if loadedConfig.MtlsDomain != "" && (loadedConfig.MtlsClientCertPath == "" || loadedConfig.MtlsClientKeyPath == "") {
+		panic("missing configuration mtls_client_cert_path or mtls_client_key_path for mtls_domain")
+	}
+
+	if loadedConfig.MtlsXfccFormat != XFCC_RAW && loadedConfig.MtlsXfccFormat != XFCC_ENVOY {
+		panic(fmt.Sprintf("unknown mtls_xfcc_format %q, expected %q or %q", loadedConfig.MtlsXfccFormat, XFCC_RAW, XFCC_ENVOY))
+	}
+
 	loadedConfig.RoutingApiUrl = fmt.Sprintf("https://%s", loadedConfig.ApiEndpoint)
 
 	return loadedConfig
@@ -0,0 +1,113 @@
+package http_routes
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Routing API routes to a backend", func() {
+	const (
+		// ROUTED_TIMEOUT is how long Gorouter may take to apply a route
+		// change from the routing API.
+		ROUTED_TIMEOUT     = 2 * time.Minute
+		DEFAULT_RW_TIMEOUT = 5 * time.Second
+	)
+
+	var (
+		appName string
+		backend helpers.InstanceAddress
+		host    string
+		client  *http.Client
+	)
+
+	routeJSON := func(ttl int) string {
+		return fmt.Sprintf(`[{"route":"%s","port":%d,"ip":"%s","ttl":%d}]`, host, backend.Port, backend.Host, ttl)
+	}
+
+	// routedStatus sends a request for the route through Gorouter, and
+	// returns the status along with the name of the app that answered.
+	routedStatus := func() (int, string, error) {
+		info, status, err := httpapp.GetRequestInfo(client, routerApiConfig.Protocol()+host+"/request")
+		if status != http.StatusOK && status != 0 {
+			// Gorouter's error responses are not request info.
+			return status, "", nil
+		}
+		return status, info.AppName, err
+	}
+
+	expectRouted := func() {
+		Eventually(func() (string, error) {
+			status, app, err := routedStatus()
+			if err == nil && status != http.StatusOK {
+				err = fmt.Errorf("%s responded with status %d", host, status)
+			}
+			return app, err
+		}, ROUTED_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(appName))
+	}
+
+	expectNotRouted := func(timeout time.Duration) {
+		Eventually(func() (int, error) {
+			status, _, err := routedStatus()
+			return status, err
+		}, timeout, DEFAULT_POLLING_INTERVAL).Should(Equal(http.StatusNotFound))
+	}
+
+	BeforeEach(func() {
+		appName = routing_helpers.GenerateAppName()
+		// The app has no routes of its own, so it is only reachable through
+		// the route registered in the spec.
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpSampleGolang, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, DEFAULT_MEMORY_LIMIT, "--no-route", "-s", "cflinuxfs4")
+		routing_helpers.StartApp(appName, CF_PUSH_TIMEOUT)
+
+		appGUID := helpers.AppGUID(appName, DEFAULT_TIMEOUT)
+		Eventually(func() []helpers.InstanceAddress {
+			return helpers.InstanceAddresses(appGUID, DEFAULT_TIMEOUT)
+		}, CF_PUSH_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveLen(1))
+		backend = helpers.InstanceAddresses(appGUID, DEFAULT_TIMEOUT)[0]
+
+		host = fmt.Sprintf("rtr-%s.%s", helpers.RandomName()[:8], routerApiConfig.AppsDomain)
+
+		routerIP := ""
+		if len(routerApiConfig.GorouterAddresses) > 0 {
+			gorouters, err := addresses.Expand(routerApiConfig.GorouterAddresses, routerApiConfig.AddressFamilies)
+			Expect(err).NotTo(HaveOccurred())
+			routerIP = gorouters[0].Host
+		}
+		client = httpapp.NewRouterClient(routerIP, &tls.Config{InsecureSkipVerify: routerApiConfig.SkipSSLValidation}, DEFAULT_RW_TIMEOUT)
+	})
+
+	AfterEach(func() {
+		// The route may already be gone, so the outcome does not matter.
+		Rtr("unregister", routeJSON(60)).Wait(DEFAULT_TIMEOUT)
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("routes requests to a registered backend until the route is unregistered", func() {
+		Eventually(Rtr("register", routeJSON(60)).Out, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Say("Successfully registered routes"))
+		expectRouted()
+
+		Eventually(Rtr("unregister", routeJSON(60)).Out, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Say("Successfully unregistered routes"))
+		expectNotRouted(ROUTED_TIMEOUT)
+	})
+
+	It("stops routing to a backend once its route expires", func() {
+		const ttl = 20
+		Eventually(Rtr("register", routeJSON(ttl)).Out, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Say("Successfully registered routes"))
+		expectRouted()
+
+		// The route is not registered again, so the routing API expires it
+		// after its TTL.
+		expectNotRouted(ttl*time.Second + ROUTED_TIMEOUT)
+	})
+})
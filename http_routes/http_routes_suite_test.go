@@ -11,6 +11,8 @@ import (
 	. "github.com/onsi/gomega/gexec"
 
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	cfworkflow_helpers "github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
 
 	"testing"
 )
@@ -40,17 +42,23 @@ const (
 	DEFAULT_MEMORY_LIMIT     = "256M"
 )
 
-var routerApiConfig helpers.RoutingConfig
+var (
+	routerApiConfig helpers.RoutingConfig
+	// environment is only set up when Config.IncludeHttpRoutes is set, for
+	// the specs that push apps as backends of routes.
+	environment *cfworkflow_helpers.ReproducibleTestSuiteSetup
+
+	// pushAssets are the assets specs push, with the buildpack that stages
+	// them.
+	pushAssets        assets.Assets
+	prebuiltAssetsDir string
+)
 
 func TestRouting(t *testing.T) {
 	RegisterFailHandler(Fail)
 
 	routerApiConfig = helpers.LoadConfig()
 
-	BeforeSuite(func() {
-		Expect(routerApiConfig.OAuth.ClientSecret).ToNot(Equal(""), "Must provide a client secret for the routing suite")
-	})
-
 	BeforeEach(func() {
 		if !routerApiConfig.IncludeHttpRoutes {
 			Skip("Skipping this test because Config.IncludeHttpRoutes is set to `false`.")
@@ -59,3 +67,28 @@ func TestRouting(t *testing.T) {
 
 	RunSpecs(t, "HTTP Routes Suite")
 }
+
+var _ = SynchronizedBeforeSuite(func() []byte {
+	return assets.PrebuildOnce(routerApiConfig.IncludeHttpRoutes && routerApiConfig.UsePrebuiltAssets)
+}, func(prebuiltDir []byte) {
+	Expect(routerApiConfig.OAuth.ClientSecret).ToNot(Equal(""), "Must provide a client secret for the routing suite")
+
+	if !routerApiConfig.IncludeHttpRoutes {
+		return
+	}
+	prebuiltAssetsDir = string(prebuiltDir)
+	pushAssets = assets.ForPush(prebuiltAssetsDir, routerApiConfig.BinaryBuildpackName, routerApiConfig.GoBuildpackName)
+	environment = cfworkflow_helpers.NewTestSuiteSetup(routerApiConfig.Config)
+	environment.Setup()
+})
+
+var _ = SynchronizedAfterSuite(func() {
+	if environment != nil {
+		environment.Teardown()
+	}
+	CleanupBuildArtifacts()
+}, func() {
+	if routerApiConfig.UsePrebuiltAssets {
+		os.RemoveAll(prebuiltAssetsDir)
+	}
+})
@@ -0,0 +1,88 @@
+package http_routing_test
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/accesslog"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gorouter access logs", func() {
+	// A documentation address, so it cannot be confused with a real hop.
+	const forwardedFor = "203.0.113.9"
+
+	var appName string
+
+	BeforeEach(func() {
+		appName = routing_helpers.GenerateAppName()
+		pushGolangApp(appName)
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("logs each routed request to the app's logs", func() {
+		appGUID := helpers.AppGUID(appName, DEFAULT_TIMEOUT)
+
+		forEachGorouter(func(client *http.Client) {
+			var info httpapp.RequestInfo
+			Eventually(func() (int, error) {
+				req, err := http.NewRequest(http.MethodGet, appURL(appName, "/request?logged=true"), nil)
+				if err != nil {
+					return 0, err
+				}
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				var status int
+				info, status, err = httpapp.DoRequestInfo(client, req)
+				return status, err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(http.StatusOK))
+			requestID := info.Headers.Get(VCAP_REQUEST_ID_HEADER)
+			Expect(requestID).NotTo(BeEmpty())
+
+			var (
+				entry    accesslog.Entry
+				unparsed []error
+			)
+			Eventually(func() ([]accesslog.Entry, error) {
+				entries, skipped, err := accesslog.Recent(appName, DEFAULT_TIMEOUT)
+				unparsed = skipped
+				entries = accesslog.WithRequestID(entries, requestID)
+				if len(entries) > 0 {
+					entry = entries[0]
+				}
+				return entries, err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveLen(1), "Gorouter should log request %s once", requestID)
+			AddReportEntry("Access log entry", entry)
+			if len(unparsed) > 0 {
+				AddReportEntry("Unparsed access log lines", unparsed)
+			}
+
+			Expect(entry.Host).To(Equal(appHost(appName)))
+			Expect(entry.Method).To(Equal(http.MethodGet))
+			Expect(entry.Path).To(Equal("/request?logged=true"))
+			Expect(entry.Status).To(Equal(http.StatusOK))
+			Expect(entry.BytesSent).To(BeNumerically(">", 0))
+			Expect(entry.ResponseTime).To(And(BeNumerically(">", 0), BeNumerically("<", DEFAULT_TIMEOUT)))
+			Expect(entry.StartedAt).To(BeTemporally("~", time.Now(), DEFAULT_TIMEOUT+time.Minute))
+
+			Expect(entry.XForwardedFor).NotTo(BeEmpty())
+			Expect(entry.XForwardedFor[0]).To(Equal(forwardedFor), "Gorouter should log the X-Forwarded-For chain it received")
+
+			Expect(entry.AppID).To(Equal(appGUID))
+			Expect(entry.AppIndex).To(Equal(info.InstanceIndex))
+			host, port, err := net.SplitHostPort(entry.Destination)
+			Expect(err).NotTo(HaveOccurred(), "the destination should be the backend address")
+			Expect(host).NotTo(BeEmpty())
+			Expect(port).NotTo(BeEmpty())
+		})
+	})
+})
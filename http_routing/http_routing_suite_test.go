@@ -0,0 +1,163 @@
+package http_routing_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+
+	. "github.com/onsi/gomega/gexec"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	cfworkflow_helpers "github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
+)
+
+func TestHttpRouting(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	routingConfig = helpers.LoadConfig()
+
+	if routingConfig.DefaultTimeout > 0 {
+		DEFAULT_TIMEOUT = time.Duration(routingConfig.DefaultTimeout) * time.Second
+	}
+
+	if routingConfig.CfPushTimeout > 0 {
+		CF_PUSH_TIMEOUT = time.Duration(routingConfig.CfPushTimeout) * time.Second
+	}
+
+	RunSpecs(t, "HTTP Routing")
+}
+
+const (
+	ROUTER_ERROR_HEADER = "X-Cf-Routererror"
+	APP_INSTANCE_HEADER = "X-Cf-App-Instance"
+
+	// Failure modes of the golang asset's /fail endpoint, as defined in
+	// assets/golang/failure.go.
+	FAIL_RESET  = "reset"
+	FAIL_REFUSE = "refuse"
+)
+
+var (
+	DEFAULT_TIMEOUT          = 2 * time.Minute
+	DEFAULT_POLLING_INTERVAL = 5 * time.Second
+	CF_PUSH_TIMEOUT          = 2 * time.Minute
+	DEFAULT_RW_TIMEOUT       = 5 * time.Second
+
+	adminContext  cfworkflow_helpers.UserContext
+	routingConfig helpers.RoutingConfig
+	environment   *cfworkflow_helpers.ReproducibleTestSuiteSetup
+	// gorouterAddresses is empty when app hostnames are resolved through DNS.
+	gorouterAddresses []addresses.Address
+
+	// pushAssets are the assets specs push, with the buildpack that stages
+	// them.
+	pushAssets        assets.Assets
+	prebuiltAssetsDir string
+)
+
+var _ = SynchronizedBeforeSuite(func() []byte {
+	return assets.PrebuildOnce(routingConfig.UsePrebuiltAssets)
+}, func(prebuiltDir []byte) {
+	prebuiltAssetsDir = string(prebuiltDir)
+	pushAssets = assets.ForPush(prebuiltAssetsDir, routingConfig.BinaryBuildpackName, routingConfig.GoBuildpackName)
+
+	environment = cfworkflow_helpers.NewTestSuiteSetup(routingConfig.Config)
+	adminContext = environment.AdminUserContext()
+	regUser := environment.RegularUserContext()
+	adminContext.TestSpace = regUser.TestSpace
+	adminContext.Org = regUser.Org
+	adminContext.Space = regUser.Space
+
+	var err error
+	gorouterAddresses, err = addresses.Expand(routingConfig.GorouterAddresses, routingConfig.AddressFamilies)
+	Expect(err).ToNot(HaveOccurred())
+
+	environment.Setup()
+})
+
+var _ = SynchronizedAfterSuite(func() {
+	environment.Teardown()
+	CleanupBuildArtifacts()
+}, func() {
+	if routingConfig.UsePrebuiltAssets {
+		os.RemoveAll(prebuiltAssetsDir)
+	}
+})
+
+// pushGolangApp pushes and starts the golang asset with a route on the apps
+// domain using appName as the hostname, and waits until it serves traffic.
+func pushGolangApp(appName string, args ...string) {
+	args = append([]string{"--no-route", "-s", "cflinuxfs4"}, args...)
+	routing_helpers.PushAppNoStart(appName, pushAssets.TcpSampleGolang, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", args...)
+	Eventually(cf.Cf("map-route", appName, routingConfig.AppsDomain, "--hostname", appName), DEFAULT_TIMEOUT).Should(Exit(0))
+	routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+
+	client := routedClient()
+	Eventually(func() (int, error) {
+		resp, err := client.Get(appURL(appName, "/"))
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(http.StatusOK))
+}
+
+func appHost(appName string) string {
+	return fmt.Sprintf("%s.%s", appName, routingConfig.AppsDomain)
+}
+
+func appURL(appName, path string) string {
+	return routingConfig.Protocol() + appHost(appName) + path
+}
+
+func tlsConfig() *tls.Config {
+	return &tls.Config{InsecureSkipVerify: routingConfig.SkipSSLValidation}
+}
+
+func httpClient() *http.Client {
+	return httpapp.NewRouterClient("", tlsConfig(), DEFAULT_RW_TIMEOUT)
+}
+
+// routedClient returns a client for specs that only need to reach an app
+// through any one Gorouter address.
+func routedClient() *http.Client {
+	if len(gorouterAddresses) > 0 {
+		return httpapp.NewRouterClient(gorouterAddresses[0].Host, tlsConfig(), DEFAULT_RW_TIMEOUT)
+	}
+	return httpClient()
+}
+
+// forEachGorouter runs check once for every Gorouter address, with a client
+// that sends requests for any app through that address. Without configured
+// addresses, check runs once with a client that resolves app hostnames
+// through DNS.
+func forEachGorouter(check func(client *http.Client)) {
+	forEachGorouterWithTLS(tlsConfig(), check)
+}
+
+// forEachGorouterWithTLS is forEachGorouter with clients that use
+// clientTLS, such as to present a client certificate.
+func forEachGorouterWithTLS(clientTLS *tls.Config, check func(client *http.Client)) {
+	if len(gorouterAddresses) == 0 {
+		By("Routing through the addresses app hostnames resolve to")
+		check(httpapp.NewRouterClient("", clientTLS, DEFAULT_RW_TIMEOUT))
+		return
+	}
+
+	helpers.ForEachAddress(gorouterAddresses, "Routing through", func(gorouterAddr addresses.Address) {
+		check(httpapp.NewRouterClient(gorouterAddr.Host, clientTLS, DEFAULT_RW_TIMEOUT))
+	})
+}
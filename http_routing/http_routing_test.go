@@ -0,0 +1,183 @@
+package http_routing_test
+
+import (
+	"fmt"
+	"net/http"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	cfworkflow_helpers "github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("HTTP routing through Gorouter", func() {
+	var (
+		appName      string
+		otherAppName string
+	)
+
+	// expectRoutedTo waits for url to be answered by the app named
+	// expectedApp through client, and returns what the app received.
+	expectRoutedTo := func(client *http.Client, url, expectedApp string) httpapp.RequestInfo {
+		var info httpapp.RequestInfo
+		Eventually(func() (string, error) {
+			var (
+				status int
+				err    error
+			)
+			info, status, err = httpapp.GetRequestInfo(client, url)
+			if err != nil {
+				return "", err
+			}
+			if status != http.StatusOK {
+				return "", fmt.Errorf("%s responded with status %d", url, status)
+			}
+			return info.AppName, nil
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(expectedApp), url)
+		return info
+	}
+
+	// expectUnknownRoute waits for Gorouter to report that no app is mapped
+	// to url.
+	expectUnknownRoute := func(client *http.Client, url string) {
+		Eventually(func() (string, error) {
+			resp, err := client.Get(url)
+			if err != nil {
+				return "", err
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusNotFound {
+				return "", fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+			}
+			return resp.Header.Get(ROUTER_ERROR_HEADER), nil
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal("unknown_route"), url)
+	}
+
+	mapRoute := func(app, domain string, args ...string) {
+		args = append([]string{"map-route", app, domain}, args...)
+		Eventually(cf.Cf(args...), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+	}
+
+	BeforeEach(func() {
+		appName = routing_helpers.GenerateAppName()
+		pushGolangApp(appName)
+	})
+
+	AfterEach(func() {
+		for _, app := range []string{appName, otherAppName} {
+			if app == "" {
+				continue
+			}
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		}
+		otherAppName = ""
+	})
+
+	It("routes each hostname to its own app", func() {
+		otherAppName = routing_helpers.GenerateAppName()
+		pushGolangApp(otherAppName)
+
+		forEachGorouter(func(client *http.Client) {
+			info := expectRoutedTo(client, appURL(appName, "/request"), appName)
+			Expect(info.Host).To(Equal(appHost(appName)))
+			expectRoutedTo(client, appURL(otherAppName, "/request"), otherAppName)
+		})
+	})
+
+	It("reports unknown hostnames as unknown routes", func() {
+		forEachGorouter(func(client *http.Client) {
+			expectUnknownRoute(client, appURL("unmapped-"+appName, "/request"))
+		})
+	})
+
+	Context("with a context path route to another app", func() {
+		BeforeEach(func() {
+			otherAppName = routing_helpers.GenerateAppName()
+			pushGolangApp(otherAppName)
+			mapRoute(otherAppName, routingConfig.AppsDomain, "--hostname", appName, "--path", "/request/other")
+		})
+
+		It("routes requests under the path to that app, and everything else to the host's app", func() {
+			forEachGorouter(func(client *http.Client) {
+				info := expectRoutedTo(client, appURL(appName, "/request/other/deeper"), otherAppName)
+				Expect(info.Path).To(Equal("/request/other/deeper"), "Gorouter must not strip the context path")
+				expectRoutedTo(client, appURL(appName, "/request/other"), otherAppName)
+
+				expectRoutedTo(client, appURL(appName, "/request"), appName)
+				// Paths match whole segments only.
+				expectRoutedTo(client, appURL(appName, "/request/otherwise"), appName)
+			})
+		})
+	})
+
+	Context("with a wildcard route", func() {
+		var (
+			domain       string
+			wildcardHost string
+		)
+
+		BeforeEach(func() {
+			// Wildcard routes on the shared apps domain would capture every
+			// unmapped host, so they go on a private domain below it.
+			domain = fmt.Sprintf("wildcard-%s.%s", helpers.RandomName()[:8], routingConfig.AppsDomain)
+			cfworkflow_helpers.AsUser(adminContext, DEFAULT_TIMEOUT, func() {
+				Eventually(cf.Cf("create-private-domain", adminContext.Org, domain), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+			})
+			DeferCleanup(func() {
+				cfworkflow_helpers.AsUser(adminContext, DEFAULT_TIMEOUT, func() {
+					Eventually(cf.Cf("delete-private-domain", domain, "-f"), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+				})
+			})
+
+			otherAppName = routing_helpers.GenerateAppName()
+			pushGolangApp(otherAppName)
+			mapRoute(appName, domain, "--hostname", "*")
+			mapRoute(otherAppName, domain, "--hostname", "specific")
+			wildcardHost = "anything-" + helpers.RandomName()[:8]
+		})
+
+		It("routes any hostname on the domain to the wildcard app, unless a more specific route matches", func() {
+			forEachGorouter(func(client *http.Client) {
+				info := expectRoutedTo(client, fmt.Sprintf("%s%s.%s/request", routingConfig.Protocol(), wildcardHost, domain), appName)
+				Expect(info.Host).To(Equal(fmt.Sprintf("%s.%s", wildcardHost, domain)))
+				expectRoutedTo(client, fmt.Sprintf("%sspecific.%s/request", routingConfig.Protocol(), domain), otherAppName)
+			})
+		})
+	})
+
+	Context("with several routes to one app", func() {
+		var secondHost string
+
+		BeforeEach(func() {
+			secondHost = "second-" + appName
+			mapRoute(appName, routingConfig.AppsDomain, "--hostname", secondHost)
+		})
+
+		It("routes every hostname to the app", func() {
+			forEachGorouter(func(client *http.Client) {
+				expectRoutedTo(client, appURL(appName, "/request"), appName)
+				info := expectRoutedTo(client, appURL(secondHost, "/request"), appName)
+				Expect(info.Host).To(Equal(appHost(secondHost)))
+			})
+		})
+
+		It("stops routing a hostname once its route is unmapped, and keeps routing the others", func() {
+			forEachGorouter(func(client *http.Client) {
+				expectRoutedTo(client, appURL(secondHost, "/request"), appName)
+			})
+
+			Eventually(cf.Cf("unmap-route", appName, routingConfig.AppsDomain, "--hostname", secondHost), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+
+			forEachGorouter(func(client *http.Client) {
+				expectUnknownRoute(client, appURL(secondHost, "/request"))
+				expectRoutedTo(client, appURL(appName, "/request"), appName)
+			})
+		})
+	})
+})
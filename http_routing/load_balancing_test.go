@@ -0,0 +1,266 @@
+package http_routing_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+const VCAP_ID_COOKIE = "__VCAP_ID__"
+
+var _ = Describe("HTTP load balancing across app instances", func() {
+	const (
+		instances = 3
+		// requests is sent for each distribution measurement, enough for
+		// every instance to receive several.
+		requests = instances * 6
+	)
+
+	var (
+		appName string
+		// host is the hostname of the app route that specs send traffic to.
+		host string
+	)
+
+	// getInstance sends a GET to url through client and returns the request
+	// info of the instance that answered.
+	getInstance := func(client *http.Client, url string) (httpapp.RequestInfo, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return httpapp.RequestInfo{}, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return httpapp.RequestInfo{}, fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return httpapp.RequestInfo{}, err
+		}
+		return httpapp.DecodeRequestInfo(body)
+	}
+
+	// distribution sends requests to the app one after another and counts
+	// how many each instance index answered.
+	distribution := func(client *http.Client) (map[string]int, error) {
+		counts := map[string]int{}
+		for i := 0; i < requests; i++ {
+			info, err := getInstance(client, appURL(host, "/request"))
+			if err != nil {
+				return counts, err
+			}
+			counts[info.InstanceIndex]++
+		}
+		return counts, nil
+	}
+
+	withJar := func(client *http.Client) *http.Client {
+		jar, err := cookiejar.New(nil)
+		Expect(err).NotTo(HaveOccurred())
+		sessionClient := *client
+		sessionClient.Jar = jar
+		return &sessionClient
+	}
+
+	vcapID := func(client *http.Client) string {
+		u, err := url.Parse(appURL(appName, "/"))
+		Expect(err).NotTo(HaveOccurred())
+		for _, cookie := range client.Jar.Cookies(u) {
+			if cookie.Name == VCAP_ID_COOKIE {
+				return cookie.Value
+			}
+		}
+		return ""
+	}
+
+	// expectSticky checks that every request in the session reaches the
+	// instance with guid.
+	expectSticky := func(client *http.Client, guid string) {
+		for i := 0; i < instances*2; i++ {
+			info, err := getInstance(client, appURL(appName, "/session"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.InstanceGUID).To(Equal(guid), "the session moved to another instance")
+		}
+	}
+
+	BeforeEach(func() {
+		appName = routing_helpers.GenerateAppName()
+		host = appName
+		pushGolangApp(appName, "-i", fmt.Sprintf("%d", instances))
+
+		forEachGorouter(func(client *http.Client) {
+			Eventually(func() (map[string]int, error) {
+				return distribution(client)
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveLen(instances), "every instance should be routable")
+		})
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	Describe("sticky sessions", func() {
+		It("keeps a session on the instance that started it", func() {
+			forEachGorouter(func(client *http.Client) {
+				session := withJar(client)
+				first, err := getInstance(session, appURL(appName, "/session"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vcapID(session)).NotTo(BeEmpty(), "Gorouter should set __VCAP_ID__ when the app sets JSESSIONID")
+
+				expectSticky(session, first.InstanceGUID)
+			})
+		})
+
+		It("moves a session to another instance when its instance stops, and keeps it there", func() {
+			session := withJar(routedClient())
+			first, err := getInstance(session, appURL(appName, "/session"))
+			Expect(err).NotTo(HaveOccurred())
+			firstVcapID := vcapID(session)
+			Expect(firstVcapID).NotTo(BeEmpty())
+
+			Eventually(cf.Cf("restart-app-instance", appName, first.InstanceIndex), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+
+			var moved httpapp.RequestInfo
+			Eventually(func() (string, error) {
+				var err error
+				moved, err = getInstance(session, appURL(appName, "/session"))
+				return moved.InstanceGUID, err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(SatisfyAny(BeEmpty(), Equal(first.InstanceGUID)))
+			Expect(vcapID(session)).NotTo(Equal(firstVcapID), "Gorouter should pin the session to its new instance")
+
+			expectSticky(session, moved.InstanceGUID)
+		})
+	})
+
+	// Gorouter gets HTTP route options with the route registrations on NATS,
+	// so these specs check them through the Cloud Controller and the traffic.
+	// TCP routes, which the routing API holds, are covered in
+	// tcp_routing/route_options_test.go.
+	Describe("load-balancing route option", func() {
+		var (
+			routeGUID    string
+			instanceZero http.Header
+			cancelBusy   context.CancelFunc
+			busy         sync.WaitGroup
+		)
+
+		// keepBusy holds connections open to instance 0 through client until
+		// the spec ends, so algorithms that count connections avoid it.
+		keepBusy := func(client *http.Client, connections int) {
+			var ctx context.Context
+			ctx, cancelBusy = context.WithCancel(context.Background())
+			busyClient := *client
+			busyClient.Timeout = 0
+			hold := fmt.Sprintf("/delay/%s", DEFAULT_TIMEOUT+time.Minute)
+
+			for i := 0; i < connections; i++ {
+				busy.Add(1)
+				go func() {
+					defer busy.Done()
+					req, err := http.NewRequestWithContext(ctx, http.MethodGet, appURL(host, hold), nil)
+					if err != nil {
+						return
+					}
+					req.Header = instanceZero
+					resp, err := busyClient.Do(req)
+					if err == nil {
+						resp.Body.Close()
+					}
+				}()
+			}
+			// Give the requests time to reach the instance.
+			time.Sleep(time.Second)
+		}
+
+		setLoadBalancing := func(algorithm string) {
+			helpers.UpdateRouteOptions(routeGUID, map[string]string{"loadbalancing": algorithm}, DEFAULT_TIMEOUT)
+		}
+
+		BeforeEach(func() {
+			routeGUID = helpers.RouteGUID(appName, routingConfig.AppsDomain, "", DEFAULT_TIMEOUT)
+			instanceZero = http.Header{APP_INSTANCE_HEADER: {helpers.AppGUID(appName, DEFAULT_TIMEOUT) + ":0"}}
+			cancelBusy = func() {}
+		})
+
+		AfterEach(func() {
+			cancelBusy()
+			busy.Wait()
+		})
+
+		It("spreads requests evenly with round-robin, even to a busy instance", func() {
+			setLoadBalancing("round-robin")
+
+			forEachGorouter(func(client *http.Client) {
+				keepBusy(client, 2)
+				defer cancelBusy()
+
+				counts, err := distribution(client)
+				Expect(err).NotTo(HaveOccurred())
+				AddReportEntry("Round-robin distribution", counts)
+				for index := 0; index < instances; index++ {
+					Expect(counts[fmt.Sprintf("%d", index)]).To(BeNumerically(">=", requests/instances/2),
+						fmt.Sprintf("instance %d got less than half its share: %v", index, counts))
+				}
+			})
+		})
+
+		It("sends fewer requests to a busy instance with least-connection", func() {
+			setLoadBalancing("least-connection")
+
+			forEachGorouter(func(client *http.Client) {
+				keepBusy(client, 2)
+				defer cancelBusy()
+
+				// Gorouter applies route options when the route is next
+				// registered, so wait for the algorithm to take effect.
+				var counts map[string]int
+				Eventually(func() (int, error) {
+					var err error
+					counts, err = distribution(client)
+					return counts["0"], err
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(BeNumerically("<", requests/instances))
+				AddReportEntry("Least-connection distribution", counts)
+			})
+		})
+
+		It("applies the load-balancing option a route was created with", func() {
+			host = "least-connection-" + appName
+			spaceGUID := helpers.SpaceGUID(environment.RegularUserContext().Space, DEFAULT_TIMEOUT)
+			optionsRouteGUID, err := helpers.CreateRoute(spaceGUID, routingConfig.AppsDomain, host, map[string]string{"loadbalancing": "least-connection"}, DEFAULT_TIMEOUT)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(helpers.RouteOptions(optionsRouteGUID, DEFAULT_TIMEOUT)).To(HaveKeyWithValue("loadbalancing", "least-connection"))
+			Eventually(cf.Cf("map-route", appName, routingConfig.AppsDomain, "--hostname", host), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+
+			forEachGorouter(func(client *http.Client) {
+				Eventually(func() (map[string]int, error) {
+					return distribution(client)
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveLen(instances), "every instance should be routable")
+
+				// Gorouter counts connections per route, so the busy
+				// connections use the same route.
+				keepBusy(client, 2)
+				defer cancelBusy()
+
+				counts, err := distribution(client)
+				Expect(err).NotTo(HaveOccurred())
+				AddReportEntry("Least-connection distribution", counts)
+				Expect(counts["0"]).To(BeNumerically("<", requests/instances), fmt.Sprintf("the busy instance got its full share: %v", counts))
+			})
+		})
+	})
+})
@@ -0,0 +1,122 @@
+package http_routing_test
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+const XFCC_HEADER = "X-Forwarded-Client-Cert"
+
+var _ = Describe("mTLS domains", func() {
+	var (
+		appName string
+		mtlsURL string
+		leaf    *x509.Certificate
+		// withCert presents the configured client certificate.
+		withCert *tls.Config
+	)
+
+	// requestWithCert waits for Gorouter to route a request for the mTLS
+	// route through client, and returns what the app received.
+	requestWithCert := func(client *http.Client, header http.Header) httpapp.RequestInfo {
+		var info httpapp.RequestInfo
+		Eventually(func() (int, error) {
+			req, err := http.NewRequest(http.MethodGet, mtlsURL, nil)
+			if err != nil {
+				return 0, err
+			}
+			for name, values := range header {
+				req.Header[name] = values
+			}
+			var status int
+			info, status, err = httpapp.DoRequestInfo(client, req)
+			return status, err
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(http.StatusOK))
+		return info
+	}
+
+	// expectedXfcc is how Gorouter should forward the client certificate in
+	// the configured format.
+	expectedXfcc := func() string {
+		if routingConfig.MtlsXfccFormat == helpers.XFCC_ENVOY {
+			hash := sha256.Sum256(leaf.Raw)
+			return fmt.Sprintf("Hash=%s;Subject=%q", hex.EncodeToString(hash[:]), leaf.Subject.String())
+		}
+		return base64.StdEncoding.EncodeToString(leaf.Raw)
+	}
+
+	BeforeEach(func() {
+		if routingConfig.MtlsDomain == "" {
+			Skip("Skipping this test because Config.MtlsDomain is not set.")
+		}
+
+		clientCert, err := tls.LoadX509KeyPair(routingConfig.MtlsClientCertPath, routingConfig.MtlsClientKeyPath)
+		Expect(err).NotTo(HaveOccurred())
+		leaf, err = x509.ParseCertificate(clientCert.Certificate[0])
+		Expect(err).NotTo(HaveOccurred())
+		withCert = tlsConfig()
+		withCert.Certificates = []tls.Certificate{clientCert}
+
+		appName = routing_helpers.GenerateAppName()
+		pushGolangApp(appName)
+		Eventually(cf.Cf("map-route", appName, routingConfig.MtlsDomain, "--hostname", appName), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+		// Client certificates are only presented over TLS, so mTLS routes
+		// are reached over HTTPS regardless of Config.UseHttp.
+		mtlsURL = fmt.Sprintf("https://%s.%s/request", appName, routingConfig.MtlsDomain)
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("forwards the client certificate to the app in the configured format", func() {
+		forEachGorouterWithTLS(withCert, func(client *http.Client) {
+			// Gorouter must not trust a certificate header sent by the
+			// client.
+			info := requestWithCert(client, http.Header{XFCC_HEADER: {"forged-by-client"}})
+
+			Expect(info.AppName).To(Equal(appName))
+			Expect(info.Headers.Values(XFCC_HEADER)).To(Equal([]string{expectedXfcc()}))
+		})
+	})
+
+	It("rejects requests without a client certificate", func() {
+		// Wait for the route first, so that unknown route errors are not
+		// mistaken for rejections.
+		forEachGorouterWithTLS(withCert, func(client *http.Client) {
+			requestWithCert(client, nil)
+		})
+
+		forEachGorouterWithTLS(tlsConfig(), func(client *http.Client) {
+			Consistently(func() error {
+				info, status, err := httpapp.GetRequestInfo(client, mtlsURL)
+				if err != nil {
+					// Gorouter may fail the handshake outright.
+					return nil
+				}
+				if status < http.StatusBadRequest || status >= http.StatusInternalServerError {
+					return fmt.Errorf("%s responded with status %d", mtlsURL, status)
+				}
+				if info.AppName != "" {
+					return fmt.Errorf("the request reached %s", info.AppName)
+				}
+				return nil
+			}, DEFAULT_RW_TIMEOUT*3, DEFAULT_RW_TIMEOUT).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,152 @@
+package http_routing_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gorouter behavior when an app instance fails", func() {
+	const (
+		instances = 3
+		// requests is enough for every instance to be picked several times.
+		requests = instances * 6
+	)
+
+	var appName string
+
+	// send sends a request with method for path through client, and returns
+	// the status, the Gorouter error if any, and the index of the instance
+	// that answered.
+	send := func(client *http.Client, method, path string) (int, string, string, error) {
+		req, err := http.NewRequest(method, appURL(appName, path), nil)
+		if err != nil {
+			return 0, "", "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, "", "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return resp.StatusCode, "", "", err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, resp.Header.Get(ROUTER_ERROR_HEADER), "", nil
+		}
+		info, err := httpapp.DecodeRequestInfo(body)
+		return resp.StatusCode, "", info.InstanceIndex, err
+	}
+
+	// failInstanceZero makes instance 0 fail all later requests with mode.
+	failInstanceZero := func(mode string) {
+		instanceZero := helpers.AppGUID(appName, DEFAULT_TIMEOUT) + ":0"
+		client := routedClient()
+		Eventually(func() (int, error) {
+			req, err := http.NewRequest(http.MethodGet, appURL(appName, "/fail/"+mode), nil)
+			if err != nil {
+				return 0, err
+			}
+			req.Header.Set(APP_INSTANCE_HEADER, instanceZero)
+			resp, err := client.Do(req)
+			if err != nil {
+				return 0, err
+			}
+			resp.Body.Close()
+			return resp.StatusCode, nil
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(http.StatusOK))
+	}
+
+	BeforeEach(func() {
+		if !routingConfig.IncludeRetryTests {
+			Skip("Skipping this test because Config.IncludeRetryTests is set to `false`.")
+		}
+
+		appName = routing_helpers.GenerateAppName()
+		// A failing instance refuses connections on its port, so Diego
+		// must not restart it for failing a port health check.
+		pushGolangApp(appName, "-i", fmt.Sprintf("%d", instances), "-u", "process")
+
+		forEachGorouter(func(client *http.Client) {
+			Eventually(func() (map[string]int, error) {
+				counts := map[string]int{}
+				for i := 0; i < requests; i++ {
+					_, _, index, err := send(client, http.MethodGet, "/request")
+					if err != nil {
+						return counts, err
+					}
+					counts[index]++
+				}
+				return counts, nil
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveLen(instances), "every instance should be routable")
+		})
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	DescribeTable("retries GET requests on another instance",
+		func(mode string) {
+			failInstanceZero(mode)
+
+			forEachGorouter(func(client *http.Client) {
+				counts := map[string]int{}
+				for i := 0; i < requests; i++ {
+					status, routerError, index, err := send(client, http.MethodGet, "/request")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(status).To(Equal(http.StatusOK), "Gorouter should have retried the request, but failed with %q", routerError)
+					counts[index]++
+				}
+				AddReportEntry("Instances answering GET requests", counts)
+				Expect(counts).NotTo(HaveKey("0"))
+			})
+		},
+		Entry("when an instance resets connections", FAIL_RESET),
+		Entry("when an instance refuses connections", FAIL_REFUSE),
+	)
+
+	It("does not retry POST requests, and stops sending requests to the failing instance", func() {
+		failInstanceZero(FAIL_RESET)
+		window := time.Duration(routingConfig.EndpointPruneWindow) * time.Second
+
+		forEachGorouter(func(client *http.Client) {
+			// Each Gorouter sends requests to the failing instance until
+			// one fails, and a POST cannot safely be sent again.
+			var routerErrors []string
+			for i := 0; i < requests; i++ {
+				status, routerError, _, err := send(client, http.MethodPost, "/request")
+				Expect(err).NotTo(HaveOccurred())
+				if status != http.StatusOK {
+					Expect(status).To(Equal(http.StatusBadGateway), routerError)
+					routerErrors = append(routerErrors, routerError)
+				}
+			}
+			Expect(routerErrors).NotTo(BeEmpty(), "a POST sent to the failing instance should fail rather than be retried")
+			Expect(routerErrors[0]).To(HavePrefix("endpoint_failure"))
+
+			Eventually(func() error {
+				for i := 0; i < requests; i++ {
+					status, routerError, _, err := send(client, http.MethodPost, "/request")
+					if err != nil {
+						return err
+					}
+					if status != http.StatusOK {
+						return fmt.Errorf("POST failed with status %d: %s", status, routerError)
+					}
+				}
+				return nil
+			}, window, time.Second).Should(Succeed(), "Gorouter should stop sending requests to the failing instance within %s", window)
+		})
+	})
+})
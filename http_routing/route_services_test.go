@@ -0,0 +1,133 @@
+package http_routing_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+// Headers set by the route-service asset.
+const (
+	ROUTE_SERVICE_HEADER        = "X-Rats-Route-Service"
+	ROUTE_SERVICE_FORWARDED_URL = "X-Rats-Route-Service-Forwarded-Url"
+	ROUTE_SERVICE_TAMPER_HEADER = "X-Rats-Tamper-Signature"
+)
+
+var _ = Describe("Route services", func() {
+	var (
+		appName          string
+		routeServiceName string
+		serviceName      string
+	)
+
+	// get sends a GET for url through client with header, and returns the
+	// response with its body.
+	get := func(client *http.Client, url string, header http.Header) (*http.Response, []byte, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp, body, err
+	}
+
+	BeforeEach(func() {
+		if !routingConfig.IncludeRouteServices {
+			Skip("Skipping this test because Config.IncludeRouteServices is set to `false`.")
+		}
+
+		appName = routing_helpers.GenerateAppName()
+		pushGolangApp(appName)
+
+		routeServiceName = routing_helpers.GenerateAppName()
+		cmd := fmt.Sprintf("route-service --serverId=%s --skipSSLValidation=%t", routeServiceName, routingConfig.SkipSSLValidation)
+		routing_helpers.PushAppNoStart(routeServiceName, pushAssets.RouteService, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4")
+		Eventually(cf.Cf("map-route", routeServiceName, routingConfig.AppsDomain, "--hostname", routeServiceName), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+		routing_helpers.StartApp(routeServiceName, DEFAULT_TIMEOUT)
+
+		serviceName = "rats-route-service-" + helpers.RandomName()[:8]
+		// Cloud Controller only accepts HTTPS route service URLs.
+		routeServiceURL := fmt.Sprintf("https://%s", appHost(routeServiceName))
+		Eventually(cf.Cf("create-user-provided-service", serviceName, "-r", routeServiceURL), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+		Eventually(cf.Cf("bind-route-service", routingConfig.AppsDomain, serviceName, "--hostname", appName), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+
+		DeferCleanup(func() {
+			Eventually(cf.Cf("unbind-route-service", routingConfig.AppsDomain, serviceName, "--hostname", appName, "-f"), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+			Eventually(cf.Cf("delete-service", serviceName, "-f"), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+		})
+	})
+
+	AfterEach(func() {
+		for _, app := range []string{appName, routeServiceName} {
+			routing_helpers.AppReport(app, DEFAULT_TIMEOUT)
+			routing_helpers.DeleteApp(app, DEFAULT_TIMEOUT)
+		}
+	})
+
+	It("sends traffic for the route through the route service, with the headers it needs", func() {
+		url := appURL(appName, "/request?through=route-service")
+
+		forEachGorouter(func(client *http.Client) {
+			var (
+				resp *http.Response
+				body []byte
+			)
+			Eventually(func() (string, error) {
+				var err error
+				resp, body, err = get(client, url, nil)
+				if err != nil {
+					return "", err
+				}
+				if resp.StatusCode != http.StatusOK {
+					return "", fmt.Errorf("%s responded with status %d: %s", url, resp.StatusCode, body)
+				}
+				return resp.Header.Get(ROUTE_SERVICE_HEADER), nil
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(routeServiceName), "the response should come back through the route service")
+
+			Expect(resp.Header.Get(ROUTE_SERVICE_FORWARDED_URL)).To(Equal(url), "Gorouter should send the original URL in X-CF-Forwarded-Url")
+
+			info, err := httpapp.DecodeRequestInfo(body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.AppName).To(Equal(appName))
+			Expect(info.RawQuery).To(Equal("through=route-service"))
+			Expect(info.Headers.Get(ROUTE_SERVICE_HEADER)).To(Equal(routeServiceName), "the request should reach the app through the route service")
+		})
+	})
+
+	It("rejects requests whose signature the route service tampered with", func() {
+		url := appURL(appName, "/request")
+		forEachGorouter(func(client *http.Client) {
+			Eventually(func() (string, error) {
+				resp, _, err := get(client, url, nil)
+				if err != nil {
+					return "", err
+				}
+				return resp.Header.Get(ROUTE_SERVICE_HEADER), nil
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(routeServiceName))
+
+			resp, body, err := get(client, url, http.Header{ROUTE_SERVICE_TAMPER_HEADER: {"true"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest), string(body))
+			Expect(resp.Header.Get(ROUTE_SERVICE_HEADER)).To(Equal(routeServiceName), "the request should have reached the route service")
+			_, err = httpapp.DecodeRequestInfo(body)
+			Expect(err).To(HaveOccurred(), "the request should not reach the app")
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package http_routing_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	VCAP_REQUEST_ID_HEADER = "X-Vcap-Request-Id"
+	B3_TRACE_ID_HEADER     = "X-B3-Traceid"
+	B3_SPAN_ID_HEADER      = "X-B3-Spanid"
+	B3_SAMPLED_HEADER      = "X-B3-Sampled"
+	B3_HEADER              = "B3"
+	TRACEPARENT_HEADER     = "Traceparent"
+	TRACESTATE_HEADER      = "Tracestate"
+)
+
+var _ = Describe("Request tracing headers", func() {
+	// Incoming trace context, as an instrumented client would send it.
+	const (
+		b3TraceID   = "463ac35c9f6413ad48485a3953bb6124"
+		b3SpanID    = "a2fb4a1d1a96d312"
+		w3cTraceID  = "0af7651916cd43dd8448eb211c80319c"
+		w3cParentID = "b7ad6b7169203331"
+		tracestate  = "rats=t61rcWkgMzE"
+	)
+
+	var appName string
+
+	// requestHeaders sends a request with header through client and returns
+	// the headers the app received.
+	requestHeaders := func(client *http.Client, header http.Header) http.Header {
+		var info httpapp.RequestInfo
+		Eventually(func() (int, error) {
+			req, err := http.NewRequest(http.MethodGet, appURL(appName, "/request"), nil)
+			if err != nil {
+				return 0, err
+			}
+			for name, values := range header {
+				req.Header[name] = values
+			}
+			var status int
+			info, status, err = httpapp.DoRequestInfo(client, req)
+			return status, err
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(Equal(http.StatusOK))
+		return info.Headers
+	}
+
+	BeforeEach(func() {
+		appName = routing_helpers.GenerateAppName()
+		pushGolangApp(appName)
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("sets a request id, and generates trace context only in the configured formats", func() {
+		AddReportEntry("Expected tracing formats", routingConfig.TracingHeaders)
+
+		forEachGorouter(func(client *http.Client) {
+			received := requestHeaders(client, nil)
+
+			Expect(received.Get(VCAP_REQUEST_ID_HEADER)).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`))
+
+			if routingConfig.TracesWith(helpers.TRACING_B3) {
+				traceID := received.Get(B3_TRACE_ID_HEADER)
+				spanID := received.Get(B3_SPAN_ID_HEADER)
+				Expect(traceID).To(MatchRegexp(`^([0-9a-f]{16}){1,2}$`))
+				Expect(spanID).To(MatchRegexp(`^[0-9a-f]{16}$`))
+				Expect(received.Get(B3_HEADER)).To(HavePrefix(fmt.Sprintf("%s-%s", traceID, spanID)))
+			} else {
+				Expect(received).NotTo(HaveKey(B3_TRACE_ID_HEADER))
+				Expect(received).NotTo(HaveKey(B3_HEADER))
+			}
+
+			if routingConfig.TracesWith(helpers.TRACING_W3C) {
+				Expect(received.Get(TRACEPARENT_HEADER)).To(MatchRegexp(`^00-[0-9a-f]{32}-[0-9a-f]{16}-0[01]$`))
+			} else {
+				Expect(received).NotTo(HaveKey(TRACEPARENT_HEADER))
+			}
+		})
+	})
+
+	It("continues incoming trace context in the configured formats, and passes other formats through unchanged", func() {
+		incoming := http.Header{
+			B3_TRACE_ID_HEADER: {b3TraceID},
+			B3_SPAN_ID_HEADER:  {b3SpanID},
+			B3_SAMPLED_HEADER:  {"1"},
+			TRACEPARENT_HEADER: {fmt.Sprintf("00-%s-%s-01", w3cTraceID, w3cParentID)},
+			TRACESTATE_HEADER:  {tracestate},
+		}
+
+		forEachGorouter(func(client *http.Client) {
+			received := requestHeaders(client, incoming)
+
+			Expect(received.Get(VCAP_REQUEST_ID_HEADER)).NotTo(BeEmpty())
+
+			Expect(received.Get(B3_TRACE_ID_HEADER)).To(Equal(b3TraceID), "the B3 trace id must be preserved")
+			if routingConfig.TracesWith(helpers.TRACING_B3) {
+				Expect(received.Get(B3_HEADER)).To(HavePrefix(b3TraceID + "-"))
+			} else {
+				Expect(received.Get(B3_SPAN_ID_HEADER)).To(Equal(b3SpanID))
+				Expect(received).NotTo(HaveKey(B3_HEADER))
+			}
+
+			traceparent := strings.Split(received.Get(TRACEPARENT_HEADER), "-")
+			Expect(traceparent).To(HaveLen(4), received.Get(TRACEPARENT_HEADER))
+			Expect(traceparent[1]).To(Equal(w3cTraceID), "the W3C trace id must be preserved")
+			Expect(received.Get(TRACESTATE_HEADER)).To(ContainSubstring(tracestate))
+			if routingConfig.TracesWith(helpers.TRACING_W3C) {
+				Expect(traceparent[2]).NotTo(Equal(w3cParentID), "Gorouter should add its own span as the parent")
+			} else {
+				Expect(traceparent[2]).To(Equal(w3cParentID))
+				Expect(received.Get(TRACESTATE_HEADER)).To(Equal(tracestate))
+			}
+		})
+	})
+})
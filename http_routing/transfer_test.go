@@ -0,0 +1,246 @@
+package http_routing_test
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request and response bodies through Gorouter", func() {
+	const LARGE_HEADER = "X-Rats-Large-Header"
+
+	var (
+		appName   string
+		largeSize int64
+	)
+
+	// transferClient lets whole bodies take longer than a single read or
+	// write would.
+	transferClient := func(client *http.Client) *http.Client {
+		c := *client
+		c.Timeout = DEFAULT_TIMEOUT
+		return &c
+	}
+
+	// readBody reads body to the end and returns its length and hex SHA-256.
+	readBody := func(body io.Reader) (int64, string) {
+		hash := sha256.New()
+		n, err := io.Copy(hash, body)
+		Expect(err).NotTo(HaveOccurred(), "the body broke off after %d bytes", n)
+		return n, hex.EncodeToString(hash.Sum(nil))
+	}
+
+	upload := func(client *http.Client, body io.Reader, contentLength int64) httpapp.UploadInfo {
+		req, err := http.NewRequest(http.MethodPost, appURL(appName, "/upload"), body)
+		Expect(err).NotTo(HaveOccurred())
+		req.ContentLength = contentLength
+		resp, err := client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK), string(respBody))
+
+		info, err := httpapp.DecodeUploadInfo(respBody)
+		Expect(err).NotTo(HaveOccurred())
+		return info
+	}
+
+	BeforeEach(func() {
+		largeSize = int64(routingConfig.LargeBodyMB) << 20
+		appName = routing_helpers.GenerateAppName()
+		pushGolangApp(appName)
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	Describe("request headers", func() {
+		sendHeader := func(client *http.Client, size int) (*http.Response, httpapp.RequestInfo) {
+			req, err := http.NewRequest(http.MethodGet, appURL(appName, "/request"), nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.Header.Set(LARGE_HEADER, strings.Repeat("h", size))
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			info, _ := httpapp.DecodeRequestInfo(body)
+			return resp, info
+		}
+
+		It("passes headers below the limit to the app", func() {
+			size := (routingConfig.MaxHeaderKB << 10) / 2
+			forEachGorouter(func(client *http.Client) {
+				resp, info := sendHeader(client, size)
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(info.Headers.Get(LARGE_HEADER)).To(HaveLen(size))
+			})
+		})
+
+		It("rejects headers above the limit with 431", func() {
+			// Gorouter reads a little past its limit before giving up.
+			size := (routingConfig.MaxHeaderKB + 16) << 10
+			forEachGorouter(func(client *http.Client) {
+				resp, info := sendHeader(client, size)
+				Expect(resp.StatusCode).To(Equal(http.StatusRequestHeaderFieldsTooLarge))
+				Expect(info.AppName).To(BeEmpty(), "the request should not reach the app")
+			})
+		})
+	})
+
+	Describe("large bodies", func() {
+		It("uploads a large body with a Content-Length byte for byte", func() {
+			forEachGorouter(func(client *http.Client) {
+				info := upload(transferClient(client), httpapp.NewBody(largeSize), largeSize)
+				Expect(info.Bytes).To(Equal(largeSize))
+				Expect(info.ContentLength).To(Equal(largeSize))
+				Expect(info.SHA256).To(Equal(httpapp.BodySHA256(largeSize)))
+			})
+		})
+
+		It("downloads a large body byte for byte", func() {
+			forEachGorouter(func(client *http.Client) {
+				resp, err := transferClient(client).Get(appURL(appName, fmt.Sprintf("/bytes/%d", largeSize)))
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.ContentLength).To(Equal(largeSize))
+
+				n, sum := readBody(resp.Body)
+				Expect(n).To(Equal(largeSize))
+				Expect(sum).To(Equal(httpapp.BodySHA256(largeSize)))
+				Expect(resp.Header.Get(httpapp.SHA256_HEADER)).To(Equal(sum))
+			})
+		})
+	})
+
+	Describe("chunked transfer encoding", func() {
+		const (
+			chunks    = 64
+			chunkSize = 16 << 10
+		)
+
+		It("passes a chunked request body to the app", func() {
+			forEachGorouter(func(client *http.Client) {
+				// An unknown length makes the client send the body chunked.
+				info := upload(transferClient(client), httpapp.NewBody(chunks*chunkSize), -1)
+				Expect(info.TransferEncoding).To(Equal([]string{"chunked"}))
+				Expect(info.Bytes).To(Equal(int64(chunks * chunkSize)))
+				Expect(info.SHA256).To(Equal(httpapp.BodySHA256(chunks * chunkSize)))
+			})
+		})
+
+		It("passes a chunked response body to the client", func() {
+			forEachGorouter(func(client *http.Client) {
+				resp, err := transferClient(client).Get(appURL(appName, fmt.Sprintf("/chunked/%d?size=%d", chunks, chunkSize)))
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(resp.TransferEncoding).To(Equal([]string{"chunked"}))
+
+				n, sum := readBody(resp.Body)
+				Expect(n).To(Equal(int64(chunks * chunkSize)))
+				Expect(sum).To(Equal(httpapp.BodySHA256(chunks * chunkSize)))
+			})
+		})
+
+		It("streams slow responses chunk by chunk instead of buffering them", func() {
+			const (
+				slowChunks = 5
+				interval   = 2 * time.Second
+			)
+			forEachGorouter(func(client *http.Client) {
+				start := time.Now()
+				resp, err := transferClient(client).Get(appURL(appName, fmt.Sprintf("/chunked/%d?interval=%s", slowChunks, interval)))
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				first := make([]byte, 1024)
+				_, err = io.ReadFull(resp.Body, first)
+				Expect(err).NotTo(HaveOccurred())
+				firstChunk := time.Since(start)
+				n, _ := readBody(resp.Body)
+				total := time.Since(start)
+				AddReportEntry("Slow response timing", fmt.Sprintf("first chunk after %s, complete after %s", firstChunk, total))
+
+				Expect(firstChunk).To(BeNumerically("<", interval*(slowChunks-1)/2), "the first chunk should arrive before the app finishes")
+				Expect(total).To(BeNumerically(">=", interval*(slowChunks-1)))
+				Expect(int64(len(first)) + n).To(Equal(int64(slowChunks * 1024)))
+			})
+		})
+	})
+
+	Describe("compression", func() {
+		// The transport only leaves bodies compressed when it does not ask
+		// for compression itself.
+		noDecompression := func(client *http.Client) *http.Client {
+			c := transferClient(client)
+			transport := c.Transport.(*http.Transport).Clone()
+			transport.DisableCompression = true
+			c.Transport = transport
+			return c
+		}
+
+		get := func(client *http.Client, acceptEncoding string) *http.Response {
+			req, err := http.NewRequest(http.MethodGet, appURL(appName, "/gzip/1048576"), nil)
+			Expect(err).NotTo(HaveOccurred())
+			if acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", acceptEncoding)
+			}
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			return resp
+		}
+
+		It("passes gzip-compressed responses through unchanged", func() {
+			forEachGorouter(func(client *http.Client) {
+				resp := get(noDecompression(client), "gzip")
+				defer resp.Body.Close()
+				Expect(resp.Header.Get("Content-Encoding")).To(Equal("gzip"))
+
+				gz, err := gzip.NewReader(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				n, sum := readBody(gz)
+				Expect(n).To(Equal(int64(1 << 20)))
+				Expect(sum).To(Equal(httpapp.BodySHA256(1 << 20)))
+			})
+		})
+
+		It("does not compress responses for clients that do not accept gzip", func() {
+			forEachGorouter(func(client *http.Client) {
+				resp := get(noDecompression(client), "")
+				defer resp.Body.Close()
+				Expect(resp.Header.Get("Content-Encoding")).To(BeEmpty())
+
+				_, sum := readBody(resp.Body)
+				Expect(sum).To(Equal(httpapp.BodySHA256(1 << 20)))
+			})
+		})
+	})
+
+	It("answers with 502 when the app's response is not HTTP", func() {
+		forEachGorouter(func(client *http.Client) {
+			resp, err := client.Get(appURL(appName, "/malformed"))
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(resp.Header.Get(ROUTER_ERROR_HEADER)).To(HavePrefix("endpoint_failure"))
+		})
+	})
+})
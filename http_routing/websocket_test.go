@@ -0,0 +1,124 @@
+package http_routing_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"github.com/gorilla/websocket"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebSockets through Gorouter", func() {
+	var (
+		appName string
+		conn    *websocket.Conn
+	)
+
+	dialWebsocket := func() (*websocket.Conn, error) {
+		dialer := websocket.Dialer{
+			TLSClientConfig:  tlsConfig(),
+			HandshakeTimeout: DEFAULT_RW_TIMEOUT,
+		}
+		url := strings.Replace(appURL(appName, "/websocket"), "http", "ws", 1)
+		conn, resp, err := dialer.Dial(url, nil)
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			GinkgoWriter.Printf("Upgrade of %s failed with status %d\n", url, resp.StatusCode)
+		}
+		return conn, err
+	}
+
+	// echo writes message and expects it back unchanged.
+	echo := func(conn *websocket.Conn, messageType int, message []byte) {
+		Expect(conn.SetWriteDeadline(time.Now().Add(DEFAULT_RW_TIMEOUT))).To(Succeed())
+		Expect(conn.WriteMessage(messageType, message)).To(Succeed())
+
+		Expect(conn.SetReadDeadline(time.Now().Add(DEFAULT_RW_TIMEOUT))).To(Succeed())
+		replyType, reply, err := conn.ReadMessage()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replyType).To(Equal(messageType))
+		Expect(bytes.Equal(reply, message)).To(BeTrue(), "the reply differs from the %d byte message sent", len(message))
+	}
+
+	BeforeEach(func() {
+		appName = routing_helpers.GenerateAppName()
+		pushGolangApp(appName)
+
+		Eventually(func() error {
+			var err error
+			conn, err = dialWebsocket()
+			return err
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+		DeferCleanup(func() { conn.Close() })
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("exchanges text and binary frames in both directions", func() {
+		for _, message := range []string{"hello", "", strings.Repeat("websocket ", 1000)} {
+			echo(conn, websocket.TextMessage, []byte(message))
+		}
+
+		binary := make([]byte, 256*1024)
+		_, err := rand.Read(binary)
+		Expect(err).NotTo(HaveOccurred())
+		echo(conn, websocket.BinaryMessage, binary)
+	})
+
+	It("answers pings with pongs carrying the same payload", func() {
+		pongs := make(chan string, 1)
+		conn.SetPongHandler(func(payload string) error {
+			pongs <- payload
+			return nil
+		})
+
+		Expect(conn.WriteControl(websocket.PingMessage, []byte("rats-ping"), time.Now().Add(DEFAULT_RW_TIMEOUT))).To(Succeed())
+		// The pong is handled while reading, and arrives before the echo.
+		echo(conn, websocket.TextMessage, []byte("after ping"))
+		Eventually(pongs).Should(Receive(Equal("rats-ping")))
+	})
+
+	It("completes the closing handshake when the client closes", func() {
+		echo(conn, websocket.TextMessage, []byte("before close"))
+
+		message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "done")
+		Expect(conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(DEFAULT_RW_TIMEOUT))).To(Succeed())
+
+		Expect(conn.SetReadDeadline(time.Now().Add(DEFAULT_RW_TIMEOUT))).To(Succeed())
+		_, _, err := conn.ReadMessage()
+		Expect(websocket.IsCloseError(err, websocket.CloseNormalClosure)).To(BeTrue(), "expected a normal closure, got %v", err)
+	})
+
+	It("closes the session with going away when the app restarts, and reconnects to the new instance", func() {
+		echo(conn, websocket.TextMessage, []byte("before restart"))
+
+		restarted := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(restarted)
+			routing_helpers.RestartApp(appName, DEFAULT_TIMEOUT)
+		}()
+
+		Expect(conn.SetReadDeadline(time.Now().Add(DEFAULT_TIMEOUT))).To(Succeed())
+		_, _, err := conn.ReadMessage()
+		Expect(websocket.IsCloseError(err, websocket.CloseGoingAway)).To(BeTrue(), "expected the app to close the session with going away, got %v", err)
+
+		Eventually(restarted, DEFAULT_TIMEOUT).Should(BeClosed())
+		var reconnected *websocket.Conn
+		Eventually(func() error {
+			var err error
+			reconnected, err = dialWebsocket()
+			return err
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+		defer reconnected.Close()
+		echo(reconnected, websocket.TextMessage, []byte("after restart"))
+	})
+})
@@ -8,18 +8,18 @@ import (
 
 	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
-	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/httpapp"
 	cfworkflow_helpers "github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
-var routerIps []string
+var routerIps []addresses.Address
 var (
 	appName                 string
 	domainName              string
-	tcpSampleGolang         = assets.NewAssets().TcpSampleGolang
 	adminContext            cfworkflow_helpers.UserContext
 	DEFAULT_RW_TIMEOUT      = 2 * time.Second
 	DEFAULT_CONNECT_TIMEOUT = 5 * time.Second
@@ -35,7 +35,9 @@ var _ = Describe("SmokeTests", func() {
 			routing_helpers.VerifySharedDomain(routingConfig.TcpAppDomain, DEFAULT_TIMEOUT)
 		})
 
-		routerIps = append(routerIps, domainName)
+		var err error
+		routerIps, err = addresses.Expand([]string{domainName}, routingConfig.AddressFamilies)
+		Expect(err).NotTo(HaveOccurred())
 		appName = routing_helpers.GenerateAppName()
 		helpers.UpdateOrgQuota(adminContext)
 	})
@@ -51,27 +53,30 @@ var _ = Describe("SmokeTests", func() {
 	})
 
 	It("map tcp route to app successfully ", func() {
-		routing_helpers.PushAppNoStart(appName, tcpSampleGolang, routingConfig.GoBuildpackName, "", CF_PUSH_TIMEOUT, "256M", "--no-route", "-s", "cflinuxfs4")
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpSampleGolang, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "--no-route", "-s", "cflinuxfs4")
 		routing_helpers.MapRandomTcpRouteToApp(appName, domainName, DEFAULT_TIMEOUT)
 		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
 		port := routing_helpers.GetPortFromAppsInfo(appName, domainName, DEFAULT_TIMEOUT)
 
 		// check tcp route is reachable from list of all Addresses
 		for _, routingAddr := range routerIps {
+			By(fmt.Sprintf("Routing through %s", routingAddr))
 			curlAppSuccess(routingAddr, port)
+			expectUnmodifiedRequest(routingAddr, port)
 		}
 
 		// delete the route and verify route is not reachable from all Addresses
 		routing_helpers.DeleteTcpRoute(domainName, port, DEFAULT_TIMEOUT)
 
 		for _, routingAddr := range routerIps {
+			By(fmt.Sprintf("Routing through %s", routingAddr))
 			curlAppFailure(routingAddr, port)
 		}
 	})
 })
 
-func curlAppSuccess(domainName, port string) {
-	appUrl := fmt.Sprintf("http://%s:%s", domainName, port)
+func curlAppSuccess(routingAddr addresses.Address, port string) {
+	appUrl := fmt.Sprintf("http://%s", net.JoinHostPort(routingAddr.Host, port))
 	fmt.Fprintf(GinkgoWriter, "\nConnecting to URL %s... \n", appUrl)
 	resp, err := http.Get(appUrl)
 	Expect(err).NotTo(HaveOccurred())
@@ -79,8 +84,20 @@ func curlAppSuccess(domainName, port string) {
 	Expect(resp.StatusCode).To(Equal(http.StatusOK))
 }
 
-func curlAppFailure(domainName, port string) {
-	appUrl := fmt.Sprintf("%s:%s", domainName, port)
+// expectUnmodifiedRequest checks that the TCP route forwarded an HTTP
+// request as it was sent, without the headers an HTTP router would add.
+func expectUnmodifiedRequest(routingAddr addresses.Address, port string) {
+	address := net.JoinHostPort(routingAddr.Host, port)
+	info, status, err := httpapp.GetRequestInfo(http.DefaultClient, fmt.Sprintf("http://%s/request", address))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(status).To(Equal(http.StatusOK))
+	Expect(info.Host).To(Equal(address))
+	Expect(info.Headers).NotTo(HaveKey("X-Forwarded-For"))
+	Expect(info.Headers).NotTo(HaveKey("X-Vcap-Request-Id"))
+}
+
+func curlAppFailure(routingAddr addresses.Address, port string) {
+	appUrl := net.JoinHostPort(routingAddr.Host, port)
 
 	dialTCP := func(url string, connFailed chan struct{}) {
 		fmt.Fprintf(GinkgoWriter, "\nConnecting to URL %s... \n", appUrl)
@@ -7,6 +7,7 @@ import (
 
 	"code.cloudfoundry.org/lager/v3/lagertest"
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
 	routing_api "code.cloudfoundry.org/routing-api"
 	cfworkflow_helpers "github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
 	. "github.com/onsi/ginkgo/v2"
@@ -22,6 +23,11 @@ var (
 	CF_PUSH_TIMEOUT          = 2 * time.Minute
 	routingConfig            helpers.RoutingConfig
 	environment              *cfworkflow_helpers.ReproducibleTestSuiteSetup
+
+	// pushAssets are the assets specs push, with the buildpack that stages
+	// them.
+	pushAssets        assets.Assets
+	prebuiltAssetsDir string
 )
 
 func TestSmokeTests(t *testing.T) {
@@ -30,7 +36,12 @@ func TestSmokeTests(t *testing.T) {
 	RunSpecs(t, "SmokeTestsSuite")
 }
 
-var _ = BeforeSuite(func() {
+var _ = SynchronizedBeforeSuite(func() []byte {
+	return assets.PrebuildOnce(routingConfig.UsePrebuiltAssets)
+}, func(prebuiltDir []byte) {
+	prebuiltAssetsDir = string(prebuiltDir)
+	pushAssets = assets.ForPush(prebuiltAssetsDir, routingConfig.BinaryBuildpackName, routingConfig.GoBuildpackName)
+
 	if routingConfig.DefaultTimeoutDuration() > 0 {
 		DEFAULT_TIMEOUT = routingConfig.DefaultTimeoutDuration()
 	}
@@ -61,7 +72,11 @@ var _ = BeforeSuite(func() {
 	helpers.ValidateRouterGroupName(adminContext, routingConfig.TCPRouterGroup)
 })
 
-var _ = AfterSuite(func() {
+var _ = SynchronizedAfterSuite(func() {
 	environment.Teardown()
 	CleanupBuildArtifacts()
+}, func() {
+	if routingConfig.UsePrebuiltAssets {
+		os.RemoveAll(prebuiltAssetsDir)
+	}
 })
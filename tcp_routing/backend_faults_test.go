@@ -0,0 +1,164 @@
+package tcp_routing_test
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/receiver"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Tcp Routing backend failures", func() {
+	var (
+		appName      string
+		serverId     string
+		externalPort uint16
+		admin        *receiver.AdminClient
+	)
+
+	BeforeEach(func() {
+		helpers.UpdateOrgQuota(adminContext)
+
+		appName = routing_helpers.GenerateAppName()
+		serverId = "faulty-server"
+		cmd := fmt.Sprintf("tcp-receiver --serverId=%s", serverId)
+		spaceName := environment.RegularUserContext().Space
+		externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+		adminPort := routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+		// Uses --no-route flag so there is no HTTP route
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+		routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+		routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+		// The admin API is served on $PORT, which a second TCP route exposes.
+		routing_helpers.MapRouteToAppWithPort(appName, domainName, adminPort, DEFAULT_TIMEOUT)
+		routing_helpers.UpdateTCPPort(appName, adminPort, []uint16{receiver.ADMIN_PORT}, DEFAULT_TIMEOUT)
+		// Anyone can reach the admin route, so only requests with the token
+		// are served.
+		adminToken := receiver.NewAdminToken()
+		Eventually(cf.Cf("set-env", appName, receiver.ADMIN_TOKEN_ENV, adminToken), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+		admin = receiver.NewAdminClient(routerAddresses[0].JoinPort(adminPort), adminToken)
+	})
+
+	JustBeforeEach(func() {
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+		Eventually(func() error {
+			_, err := admin.State()
+			return err
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("passes added backend latency through to the client", func() {
+		latency := 2 * time.Second
+		Expect(admin.SetLatency(latency)).To(Succeed())
+
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			conn, err := net.DialTimeout(CONN_TYPE, routerAddr.JoinPort(externalPort), DEFAULT_CONNECT_TIMEOUT)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			start := time.Now()
+			resp, err := tcpclient.Exchange(conn, []byte("slow"), latency+DEFAULT_RW_TIMEOUT)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(resp)).To(Equal(serverId + ":slow"))
+			Expect(time.Since(start)).To(BeNumerically(">=", latency))
+		})
+	})
+
+	It("closes client connections when the backend resets them", func() {
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			conn, err := net.DialTimeout(CONN_TYPE, routerAddr.JoinPort(externalPort), DEFAULT_CONNECT_TIMEOUT)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			_, err = tcpclient.Exchange(conn, []byte("before reset"), DEFAULT_RW_TIMEOUT)
+			Expect(err).NotTo(HaveOccurred())
+
+			reset, err := admin.ResetConnections()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reset).To(BeNumerically(">=", 1))
+
+			Eventually(func() error {
+				_, err := tcpclient.Exchange(conn, []byte("after reset"), DEFAULT_RW_TIMEOUT)
+				return err
+			}, DEFAULT_TIMEOUT, time.Second).Should(HaveOccurred())
+		})
+	})
+
+	It("fails new connections while the backend refuses them and recovers afterwards", func() {
+		Expect(admin.SetRefuse(true)).To(Succeed())
+
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			Eventually(func() error {
+				_, err := sendAndReceive(routerAddr, externalPort)
+				return err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveOccurred())
+		})
+
+		Expect(admin.SetRefuse(false)).To(Succeed())
+
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			Eventually(func() (string, error) {
+				return sendAndReceive(routerAddr, externalPort)
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+		})
+	})
+
+	Context("with a readiness health check", func() {
+		BeforeEach(func() {
+			if !routingConfig.IncludeReadinessTests {
+				Skip("Skipping this test because Config.IncludeReadinessTests is set to `false`.")
+			}
+			// The receiver reports whether it is ready on the admin API.
+			Eventually(cf.Cf("set-readiness-health-check", appName, "http", "--endpoint", "/ready"), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+		})
+
+		It("stops routing to the backend while it is not ready", func() {
+			appGUID := helpers.AppGUID(appName, DEFAULT_TIMEOUT)
+			Expect(admin.SetReady(false)).To(Succeed())
+
+			Eventually(func() []helpers.InstanceAddress {
+				return helpers.InstanceAddresses(appGUID, DEFAULT_TIMEOUT)
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ConsistOf(
+				HaveField("Routable", BeFalse()),
+			), "the instance should keep running but fail its readiness check")
+
+			// Without a routable instance the admin route is gone too, so the
+			// receiver cannot be made ready again.
+			forEachRouterAddress(func(routerAddr addresses.Address) {
+				Eventually(func() error {
+					_, err := sendAndReceive(routerAddr, externalPort)
+					return err
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveOccurred())
+			})
+		})
+	})
+
+	It("routes to the restarted instance after the backend crashes", func() {
+		Expect(admin.Crash(1)).To(Succeed())
+		Eventually(func() error {
+			_, err := admin.State()
+			return err
+		}, DEFAULT_TIMEOUT, 500*time.Millisecond).Should(HaveOccurred())
+
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			Eventually(func() (string, error) {
+				return sendAndReceive(routerAddr, externalPort)
+			}, CF_PUSH_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId))
+		})
+	})
+})
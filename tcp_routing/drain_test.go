@@ -0,0 +1,138 @@
+package tcp_routing_test
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Tcp Routing while apps drain", func() {
+	const (
+		responseLatency = time.Second
+		drainTimeout    = 8 * time.Second
+		workers         = 5
+	)
+
+	var (
+		appName      string
+		serverId     string
+		externalPort uint16
+	)
+
+	// runTraffic keeps exchanges in flight through every router address
+	// while disrupt runs, and returns the results once traffic has resumed.
+	runTraffic := func(disrupt func()) []tcpclient.TrafficResult {
+		opts := tcpclient.TrafficOptions{
+			Workers:   workers,
+			RWTimeout: responseLatency + DEFAULT_RW_TIMEOUT,
+		}
+
+		stop := make(chan struct{})
+		results := make([]tcpclient.TrafficResult, len(routerAddresses))
+		wg := sync.WaitGroup{}
+		for i, routerAddr := range routerAddresses {
+			wg.Add(1)
+			go func(i int, address string) {
+				defer wg.Done()
+				results[i] = tcpclient.RunGreetedTraffic(address, opts, stop)
+			}(i, routerAddr.JoinPort(externalPort))
+		}
+
+		// Make sure exchanges are in flight before disrupting the app.
+		time.Sleep(3 * responseLatency)
+		disrupt()
+
+		close(stop)
+		wg.Wait()
+
+		for i, result := range results {
+			AddReportEntry(fmt.Sprintf("Traffic through %s", routerAddresses[i]), result)
+		}
+		return results
+	}
+
+	expectCleanDrain := func() {
+		Eventually(func() *gbytes.Buffer {
+			session := cf.Cf("logs", appName, "--recent")
+			Eventually(session, DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+			return session.Out
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(gbytes.Say(`Drained in .* forcibly_closed=0`))
+	}
+
+	BeforeEach(func() {
+		helpers.UpdateOrgQuota(adminContext)
+
+		appName = routing_helpers.GenerateAppName()
+		serverId = "draining-server"
+		cmd := fmt.Sprintf("tcp-receiver --serverId=%s --mode=greeting --latency=%s --drainTimeout=%s", serverId, responseLatency, drainTimeout)
+		spaceName := environment.RegularUserContext().Space
+		externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+		// Uses --no-route flag so there is no HTTP route
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+		routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+		routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+
+		for _, routerAddr := range routerAddresses {
+			Eventually(func() error {
+				_, _, err := tcpclient.GreetedExchange(routerAddr.JoinPort(externalPort), "ping", tcpclient.TrafficOptions{
+					ConnectTimeout: DEFAULT_CONNECT_TIMEOUT,
+					RWTimeout:      responseLatency + DEFAULT_RW_TIMEOUT,
+				})
+				return err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("does not truncate in-flight exchanges when the app is restarted", func() {
+		results := runTraffic(func() {
+			routing_helpers.RestartApp(appName, DEFAULT_TIMEOUT)
+			// Keep the traffic going until it reaches the new instance.
+			for _, routerAddr := range routerAddresses {
+				Eventually(func() error {
+					_, _, err := tcpclient.GreetedExchange(routerAddr.JoinPort(externalPort), "after restart", tcpclient.TrafficOptions{
+						ConnectTimeout: DEFAULT_CONNECT_TIMEOUT,
+						RWTimeout:      responseLatency + DEFAULT_RW_TIMEOUT,
+					})
+					return err
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+			}
+		})
+
+		for _, result := range results {
+			Expect(result.Completed).To(BeNumerically(">", 0), result.String())
+			Expect(result.Truncated).To(BeZero(), result.String())
+		}
+		expectCleanDrain()
+	})
+
+	It("does not truncate in-flight exchanges when the app is stopped", func() {
+		results := runTraffic(func() {
+			Eventually(cf.Cf("stop", appName), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+			// Give the routers time to stop routing to the stopped instance.
+			time.Sleep(drainTimeout)
+		})
+
+		for _, result := range results {
+			Expect(result.Completed).To(BeNumerically(">", 0), result.String())
+			Expect(result.Truncated).To(BeZero(), result.String())
+		}
+		expectCleanDrain()
+	})
+})
@@ -0,0 +1,160 @@
+package tcp_routing_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/grpcclient"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("gRPC routing", func() {
+	const (
+		// The app serves h2c on $PORT and TLS on TLS_APP_PORT.
+		H2C_APP_PORT     = 8080
+		TLS_APP_PORT     = 3333
+		streamMessages   = 200
+		streamMessageLen = 16 * 1024
+	)
+
+	var (
+		appName    string
+		serverId   string
+		serverName string
+	)
+
+	// expectEcho waits for a unary call through client to succeed, then checks
+	// that it reached the app over transport.
+	expectEcho := func(client *grpcclient.Client, transport string) {
+		var (
+			reply    string
+			identity grpcclient.Identity
+		)
+		Eventually(func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_RW_TIMEOUT)
+			defer cancel()
+			var err error
+			reply, identity, err = client.Echo(ctx, "unary call")
+			return err
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+
+		Expect(reply).To(Equal("unary call"))
+		Expect(identity).To(Equal(grpcclient.Identity{ServerId: serverId, Transport: transport}))
+	}
+
+	// expectOrderedStream streams messages in both directions through client
+	// and checks that every one of them came back intact and in order.
+	expectOrderedStream := func(client *grpcclient.Client, transport string) {
+		messages := grpcclient.ChatMessages(streamMessages, streamMessageLen)
+		ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_TIMEOUT)
+		defer cancel()
+
+		replies, identity, err := client.Chat(ctx, messages)
+		Expect(err).NotTo(HaveOccurred(), fmt.Sprintf("after %d of %d replies", len(replies), len(messages)))
+		Expect(identity).To(Equal(grpcclient.Identity{ServerId: serverId, Transport: transport}))
+		Expect(replies).To(HaveLen(len(messages)))
+		for i := range messages {
+			Expect(replies[i]).To(Equal(messages[i]), fmt.Sprintf("reply %d was reordered or corrupted", i))
+		}
+	}
+
+	newClient := func(target string, tlsConfig *tls.Config) *grpcclient.Client {
+		client, err := grpcclient.NewClient(target, tlsConfig)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(client.Close)
+		return client
+	}
+
+	BeforeEach(func() {
+		appName = routing_helpers.GenerateAppName()
+		serverId = "grpc-server"
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	Context("over a TCP route", func() {
+		var (
+			h2cPort uint16
+			tlsPort uint16
+		)
+
+		BeforeEach(func() {
+			helpers.UpdateOrgQuota(adminContext)
+
+			serverName = fmt.Sprintf("%s.%s", appName, domainName)
+			cmd := fmt.Sprintf("grpc-echo --serverId=%s --tlsAddress=0.0.0.0:%d --tlsSAN=%s", serverId, TLS_APP_PORT, serverName)
+			spaceName := environment.RegularUserContext().Space
+			h2cPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+			tlsPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+			// Uses --no-route flag so there is no HTTP route
+			routing_helpers.PushAppNoStart(appName, pushAssets.GrpcEcho, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+			routing_helpers.MapRouteToAppWithPort(appName, domainName, h2cPort, DEFAULT_TIMEOUT)
+			routing_helpers.UpdateTCPPort(appName, h2cPort, []uint16{H2C_APP_PORT}, DEFAULT_TIMEOUT)
+			routing_helpers.MapRouteToAppWithPort(appName, domainName, tlsPort, DEFAULT_TIMEOUT)
+			routing_helpers.UpdateTCPPort(appName, tlsPort, []uint16{TLS_APP_PORT}, DEFAULT_TIMEOUT)
+			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+		})
+
+		Context("in cleartext", func() {
+			It("makes unary and bidirectional streaming calls through every router address", func() {
+				forEachRouterAddress(func(routerAddr addresses.Address) {
+					client := newClient(routerAddr.JoinPort(h2cPort), nil)
+					expectEcho(client, "h2c")
+					expectOrderedStream(client, "h2c")
+				})
+			})
+		})
+
+		Context("over TLS", func() {
+			It("makes unary and bidirectional streaming calls through every router address", func() {
+				forEachRouterAddress(func(routerAddr addresses.Address) {
+					// The app's certificate is self-signed; the TLS passthrough
+					// tests check that it arrives unchanged.
+					client := newClient(routerAddr.JoinPort(tlsPort), &tls.Config{
+						ServerName:         serverName,
+						InsecureSkipVerify: true,
+					})
+					expectEcho(client, "tls")
+					expectOrderedStream(client, "tls")
+				})
+			})
+		})
+	})
+
+	Context("over an HTTP route", func() {
+		BeforeEach(func() {
+			if !routingConfig.IncludeHttp2Routes {
+				Skip("Skipping this test because Config.IncludeHttp2Routes is set to `false`.")
+			}
+
+			serverName = fmt.Sprintf("%s.%s", appName, routingConfig.AppsDomain)
+			cmd := fmt.Sprintf("grpc-echo --serverId=%s", serverId)
+
+			routing_helpers.PushAppNoStart(appName, pushAssets.GrpcEcho, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4")
+			Eventually(cf.Cf("map-route", appName, routingConfig.AppsDomain, "--hostname", appName, "--app-protocol", "http2"), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+		})
+
+		It("makes unary and bidirectional streaming calls to an HTTP/2 destination", func() {
+			client := newClient(serverName+":443", &tls.Config{
+				ServerName:         serverName,
+				InsecureSkipVerify: routingConfig.SkipSSLValidation,
+			})
+			// Gorouter terminates TLS and speaks h2c to the app.
+			expectEcho(client, "h2c")
+			expectOrderedStream(client, "h2c")
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package tcp_routing_test
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tcp Routing idle timeout", func() {
+	var (
+		appName      string
+		externalPort uint16
+		idleTimeout  time.Duration
+		tolerance    time.Duration
+	)
+
+	BeforeEach(func() {
+		if routingConfig.ExpectedIdleTimeout <= 0 {
+			Skip("Skipping this test because Config.ExpectedIdleTimeout is not set.")
+		}
+		idleTimeout = time.Duration(routingConfig.ExpectedIdleTimeout) * time.Second
+		tolerance = idleTimeout / 10
+		if tolerance < 2*time.Second {
+			tolerance = 2 * time.Second
+		}
+
+		helpers.UpdateOrgQuota(adminContext)
+
+		appName = routing_helpers.GenerateAppName()
+		cmd := "tcp-receiver --serverId=idle-server"
+		spaceName := environment.RegularUserContext().Space
+		externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+		// Uses --no-route flag so there is no HTTP route
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+		routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+		routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	// dialAndExchange opens a connection and exchanges one message, so that
+	// the router has connected through to the app before the idle period.
+	dialAndExchange := func(routerAddr addresses.Address) net.Conn {
+		var conn net.Conn
+		Eventually(func() error {
+			var err error
+			conn, err = net.DialTimeout(CONN_TYPE, routerAddr.JoinPort(externalPort), DEFAULT_CONNECT_TIMEOUT)
+			if err != nil {
+				return err
+			}
+			_, err = tcpclient.Exchange(conn, []byte("hello"), DEFAULT_RW_TIMEOUT)
+			if err != nil {
+				conn.Close()
+			}
+			return err
+		}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+		return conn
+	}
+
+	It("closes idle connections after the expected idle timeout", func() {
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			conn := dialAndExchange(routerAddr)
+			defer conn.Close()
+
+			idle, err := tcpclient.MeasureIdleClose(conn, idleTimeout+tolerance)
+			AddReportEntry(fmt.Sprintf("Idle timeout measured through %s", routerAddr), idle.Round(time.Millisecond).String())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(idle).To(BeNumerically("~", idleTimeout, tolerance))
+		})
+	})
+
+	It("keeps connections with keepalive traffic open past the idle timeout", func() {
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			conn := dialAndExchange(routerAddr)
+			defer conn.Close()
+
+			keepAliveFor := idleTimeout + 2*tolerance
+			err := tcpclient.KeepAlive(conn, idleTimeout/3, keepAliveFor, DEFAULT_RW_TIMEOUT)
+			Expect(err).ToNot(HaveOccurred())
+			AddReportEntry(fmt.Sprintf("Connection kept alive through %s", routerAddr), keepAliveFor.String())
+		})
+	})
+})
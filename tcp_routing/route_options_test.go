@@ -0,0 +1,134 @@
+package tcp_routing_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	routing_api "code.cloudfoundry.org/routing-api"
+	"code.cloudfoundry.org/routing-api/models"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Tcp Routing route options", func() {
+	var spaceGUID string
+
+	BeforeEach(func() {
+		helpers.UpdateOrgQuota(adminContext)
+		spaceGUID = helpers.SpaceGUID(environment.RegularUserContext().Space, DEFAULT_TIMEOUT)
+	})
+
+	// createRoute creates a TCP route through the Cloud Controller v3 API,
+	// and deletes it after the spec whenever the Cloud Controller created it.
+	createRoute := func(options map[string]string) (string, error) {
+		routeGUID, err := helpers.CreateRoute(spaceGUID, domainName, "", options, DEFAULT_TIMEOUT)
+		if routeGUID != "" {
+			DeferCleanup(func() {
+				Eventually(cf.Cf("curl", "--fail", "/v3/routes/"+routeGUID, "-X", "DELETE"), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+			})
+		}
+		return routeGUID, err
+	}
+
+	It("creates TCP routes without options", func() {
+		routeGUID, err := createRoute(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(helpers.RouteOptions(routeGUID, DEFAULT_TIMEOUT)).To(BeEmpty())
+	})
+
+	DescribeTable("rejects options on TCP routes",
+		func(options map[string]string, mention string) {
+			_, err := createRoute(options)
+			Expect(err).To(HaveOccurred(), "the Cloud Controller accepted options %v for a TCP route", options)
+
+			var ccErr helpers.CCError
+			Expect(errors.As(err, &ccErr)).To(BeTrue(), err.Error())
+			Expect(ccErr.Code).To(Equal(10008))
+			Expect(ccErr.Title).To(Equal("CF-UnprocessableEntity"))
+			Expect(ccErr.Detail).To(ContainSubstring(mention))
+		},
+		// The Cloud Controller rejects every option on TCP routes without
+		// naming it, so this entry looks for the route type instead.
+		Entry("loadbalancing, which only HTTP routes support",
+			map[string]string{"loadbalancing": "round-robin"},
+			"TCP routes"),
+		Entry("an unknown option",
+			map[string]string{"rats-unknown-option": "true"},
+			"rats-unknown-option"),
+	)
+
+	Context("when a route created through the v3 API is mapped to an app", func() {
+		var (
+			appName      string
+			externalPort uint16
+			// portEvents receives the routing API's TCP events for
+			// externalPort.
+			portEvents chan routing_api.TcpEvent
+		)
+
+		BeforeEach(func() {
+			routeGUID, err := createRoute(nil)
+			Expect(err).NotTo(HaveOccurred())
+			externalPort = helpers.RoutePort(routeGUID, DEFAULT_TIMEOUT)
+
+			// Subscribe before the route is mapped, so that the events
+			// adding it are not missed.
+			events, err := routingApiClient.SubscribeToTcpEvents()
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(events.Close)
+			portEvents = make(chan routing_api.TcpEvent, 10)
+			go func() {
+				defer close(portEvents)
+				for {
+					event, err := events.Next()
+					if err != nil {
+						return
+					}
+					if event.TcpRouteMapping.ExternalPort != externalPort {
+						continue
+					}
+					select {
+					case portEvents <- event:
+					default:
+					}
+				}
+			}()
+
+			appName = routing_helpers.GenerateAppName()
+			cmd := fmt.Sprintf("tcp-receiver --serverId=%s", appName)
+			routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+			routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+			routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+		})
+
+		AfterEach(func() {
+			routing_helpers.AppReport(appName, 2*time.Minute)
+			routing_helpers.DeleteApp(appName, 2*time.Minute)
+		})
+
+		It("propagates the route into the routing API's TCP route mappings and events", func() {
+			instances := helpers.InstanceAddresses(helpers.AppGUID(appName, DEFAULT_TIMEOUT), DEFAULT_TIMEOUT)
+			Expect(instances).NotTo(BeEmpty())
+			toInstance := And(
+				HaveField("ExternalPort", externalPort),
+				HaveField("HostIP", instances[0].Host),
+			)
+
+			Eventually(func() ([]models.TcpRouteMapping, error) {
+				return routingApiClient.TcpRouteMappings()
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainElement(toInstance))
+			Eventually(portEvents, DEFAULT_TIMEOUT).Should(Receive(And(
+				HaveField("Action", "Upsert"),
+				HaveField("TcpRouteMapping", toInstance),
+			)))
+		})
+	})
+})
@@ -0,0 +1,67 @@
+package tcp_routing_test
+
+import (
+	"fmt"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tcp Routing at scale", func() {
+	var (
+		appName      string
+		serverId     string
+		externalPort uint16
+	)
+
+	BeforeEach(func() {
+		if !routingConfig.IncludeScaleTests {
+			Skip("Skipping this test because Config.IncludeScaleTests is set to `false`.")
+		}
+
+		helpers.UpdateOrgQuota(adminContext)
+
+		appName = routing_helpers.GenerateAppName()
+		serverId = "scale-server"
+		cmd := fmt.Sprintf("tcp-receiver --serverId=%s --quiet", serverId)
+		spaceName := environment.RegularUserContext().Space
+		externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+		// Uses --no-route flag so there is no HTTP route
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+		routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+		routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("holds many concurrent connections through every router address", func() {
+		opts := tcpclient.ScaleOptions{
+			Connections:  routingConfig.ScaleTestConnections,
+			HoldDuration: time.Duration(routingConfig.ScaleTestHoldDuration) * time.Second,
+		}
+
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			Eventually(func() error {
+				_, err := sendAndReceive(routerAddr, externalPort)
+				return err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+
+			result := tcpclient.RunScale(routerAddr.JoinPort(externalPort), opts)
+			AddReportEntry(fmt.Sprintf("Scale results for %s", routerAddr), result)
+
+			Expect(result.Succeeded).To(Equal(result.Attempted), result.String())
+			Expect(result.Dropped).To(BeZero(), result.String())
+		})
+	})
+})
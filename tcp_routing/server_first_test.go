@@ -0,0 +1,98 @@
+package tcp_routing_test
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tcp Routing to server-first apps", func() {
+	const connections = 5
+
+	var (
+		appName      string
+		serverId     string
+		banner       string
+		externalPort uint16
+	)
+
+	// readBanner connects through routerAddr and reads the banner without
+	// writing anything, returning how long it took to arrive.
+	readBanner := func(routerAddr addresses.Address) (string, time.Duration, error) {
+		conn, err := net.DialTimeout(CONN_TYPE, routerAddr.JoinPort(externalPort), DEFAULT_CONNECT_TIMEOUT)
+		if err != nil {
+			return "", 0, err
+		}
+		defer conn.Close()
+
+		start := time.Now()
+		greeting, err := tcpclient.ReadGreeting(conn, DEFAULT_RW_TIMEOUT)
+		return string(greeting), time.Since(start), err
+	}
+
+	BeforeEach(func() {
+		helpers.UpdateOrgQuota(adminContext)
+
+		appName = routing_helpers.GenerateAppName()
+		serverId = "server-first"
+		banner = fmt.Sprintf("220 %s ready\r\n", serverId)
+		cmd := fmt.Sprintf("tcp-receiver --serverId=%s --mode=greeting", serverId)
+		spaceName := environment.RegularUserContext().Space
+		externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+		// Uses --no-route flag so there is no HTTP route
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+		routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+		routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+
+		for _, routerAddr := range routerAddresses {
+			Eventually(func() error {
+				_, _, err := readBanner(routerAddr)
+				return err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("delivers the banner before the client writes anything", func() {
+		maxLatency := time.Duration(routingConfig.MaxGreetingLatencyMs) * time.Millisecond
+
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			var slowest time.Duration
+			for i := 0; i < connections; i++ {
+				greeting, latency, err := readBanner(routerAddr)
+				Expect(err).ToNot(HaveOccurred(), fmt.Sprintf("reading the banner through %s, received %q", routerAddr, greeting))
+				Expect(greeting).To(Equal(banner))
+				Expect(latency).To(BeNumerically("<=", maxLatency),
+					fmt.Sprintf("banner took %s to arrive through %s", latency, routerAddr))
+				slowest = max(slowest, latency)
+			}
+			AddReportEntry(fmt.Sprintf("Slowest banner through %s", routerAddr), slowest.String())
+		})
+	})
+
+	It("keeps the connection usable after the banner", func() {
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			accepted, response, err := tcpclient.GreetedExchange(routerAddr.JoinPort(externalPort), "EHLO client", tcpclient.TrafficOptions{
+				ConnectTimeout: DEFAULT_CONNECT_TIMEOUT,
+				RWTimeout:      DEFAULT_RW_TIMEOUT,
+			})
+			Expect(err).ToNot(HaveOccurred(), fmt.Sprintf("through %s, received %q", routerAddr, response))
+			Expect(accepted).To(BeTrue())
+			Expect(string(response)).To(ContainSubstring(serverId))
+		})
+	})
+})
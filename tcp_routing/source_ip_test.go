@@ -0,0 +1,95 @@
+package tcp_routing_test
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tcp Routing source IP preservation", func() {
+	var (
+		appName      string
+		externalPort uint16
+	)
+
+	BeforeEach(func() {
+		if !routingConfig.IncludeProxyProtocolTests {
+			Skip("Skipping this test because Config.IncludeProxyProtocolTests is set to `false`.")
+		}
+
+		helpers.UpdateOrgQuota(adminContext)
+
+		appName = routing_helpers.GenerateAppName()
+		cmd := "tcp-receiver --serverId=proxy-server --proxyProtocol --reportClientAddress"
+		spaceName := environment.RegularUserContext().Space
+		externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+		// Uses --no-route flag so there is no HTTP route
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+		routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+		routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("reports the test runner's egress IP as the client address", func() {
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			var (
+				reported  string
+				localAddr net.Addr
+			)
+			Eventually(func() error {
+				var err error
+				reported, localAddr, err = reportedClientAddress(routerAddr, externalPort)
+				return err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+
+			expectedIP := routingConfig.EgressIP
+			if expectedIP == "" {
+				expectedIP = localAddr.(*net.TCPAddr).IP.String()
+			}
+
+			reportedHost, _, err := net.SplitHostPort(reported)
+			Expect(err).ToNot(HaveOccurred(), fmt.Sprintf("app reported client address %q", reported))
+			AddReportEntry(fmt.Sprintf("Client address seen through %s", routerAddr), reported)
+
+			Expect(net.ParseIP(reportedHost).Equal(net.ParseIP(expectedIP))).To(BeTrue(),
+				fmt.Sprintf("app saw client %s through %s, expected %s", reportedHost, routerAddr, expectedIP))
+		})
+	})
+})
+
+// reportedClientAddress returns the client address the app reports for a
+// connection, along with the local address the connection was made from.
+func reportedClientAddress(addr addresses.Address, externalPort uint16) (string, net.Addr, error) {
+	conn, err := net.DialTimeout(CONN_TYPE, addr.JoinPort(externalPort), DEFAULT_CONNECT_TIMEOUT)
+	if err != nil {
+		return "", nil, err
+	}
+	defer conn.Close()
+
+	message := []byte(fmt.Sprintf("Time is %d", time.Now().Nanosecond()))
+	resp, err := tcpclient.Exchange(conn, message, DEFAULT_RW_TIMEOUT)
+	if err != nil {
+		return "", nil, err
+	}
+
+	reported := tcpclient.ReportedField(resp, "client_address")
+	if reported == "" {
+		return "", nil, fmt.Errorf("no client address in response %q", resp)
+	}
+
+	return reported, conn.LocalAddr(), nil
+}
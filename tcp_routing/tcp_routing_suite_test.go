@@ -2,6 +2,7 @@ package tcp_routing_test
 
 import (
 	"context"
+	"os"
 	"time"
 
 	"code.cloudfoundry.org/lager/v3"
@@ -14,6 +15,8 @@ import (
 	. "github.com/onsi/gomega/gexec"
 
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
 	routing_api "code.cloudfoundry.org/routing-api"
 	cfworkflow_helpers "github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
 )
@@ -42,12 +45,23 @@ var (
 
 	adminContext     cfworkflow_helpers.UserContext
 	routingConfig    helpers.RoutingConfig
+	routerAddresses  []addresses.Address
 	routingApiClient routing_api.Client
 	environment      *cfworkflow_helpers.ReproducibleTestSuiteSetup
 	logger           lager.Logger
+
+	// pushAssets are the assets specs push, with the buildpack that stages
+	// them.
+	pushAssets        assets.Assets
+	prebuiltAssetsDir string
 )
 
-var _ = BeforeSuite(func() {
+var _ = SynchronizedBeforeSuite(func() []byte {
+	return assets.PrebuildOnce(routingConfig.UsePrebuiltAssets)
+}, func(prebuiltDir []byte) {
+	prebuiltAssetsDir = string(prebuiltDir)
+	pushAssets = assets.ForPush(prebuiltAssetsDir, routingConfig.BinaryBuildpackName, routingConfig.GoBuildpackName)
+
 	logger = lagertest.NewTestLogger("test")
 	routingApiClient = routing_api.NewClient(routingConfig.RoutingApiUrl, routingConfig.SkipSSLValidation)
 
@@ -67,12 +81,19 @@ var _ = BeforeSuite(func() {
 	adminContext.Space = regUser.Space
 	domainName = routingConfig.TcpAppDomain
 
+	routerAddresses, err = addresses.Expand(routingConfig.Addresses, routingConfig.AddressFamilies)
+	Expect(err).ToNot(HaveOccurred())
+
 	environment.Setup()
 
 	helpers.ValidateRouterGroupName(adminContext, routingConfig.TCPRouterGroup)
 })
 
-var _ = AfterSuite(func() {
+var _ = SynchronizedAfterSuite(func() {
 	environment.Teardown()
 	CleanupBuildArtifacts()
+}, func() {
+	if routingConfig.UsePrebuiltAssets {
+		os.RemoveAll(prebuiltAssetsDir)
+	}
 })
@@ -1,19 +1,20 @@
 package tcp_routing_test
 
 import (
-	"errors"
 	"fmt"
 	"net"
-	"strings"
 	"time"
 
 	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
-	"code.cloudfoundry.org/routing-acceptance-tests/helpers/assets"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/receiver"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
 )
 
 var _ = Describe("Tcp Routing", func() {
@@ -23,21 +24,20 @@ var _ = Describe("Tcp Routing", func() {
 
 	Context("single app port", func() {
 		var (
-			appName            string
-			tcpDropletReceiver = assets.NewAssets().TcpDropletReceiver
-			serverId1          string
-			externalPort1      uint16
-			spaceName          string
+			appName       string
+			serverId1     string
+			externalPort1 uint16
+			spaceName     string
 		)
 
 		BeforeEach(func() {
 			appName = routing_helpers.GenerateAppName()
 			serverId1 = "server1"
-			cmd := fmt.Sprintf("tcp-droplet-receiver --serverId=%s", serverId1)
+			cmd := fmt.Sprintf("tcp-receiver --serverId=%s --format=json", serverId1)
 			spaceName = environment.RegularUserContext().Space
 			externalPort1 = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
 
-			routing_helpers.PushAppNoStart(appName, tcpDropletReceiver, routingConfig.GoBuildpackName, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+			routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
 			routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort1, DEFAULT_TIMEOUT)
 			routing_helpers.UpdateTCPPort(appName, externalPort1, []uint16{3333}, DEFAULT_TIMEOUT)
 			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
@@ -49,7 +49,7 @@ var _ = Describe("Tcp Routing", func() {
 		})
 
 		It("maps a single external port to an application's container port", func() {
-			for _, routerAddr := range routingConfig.Addresses {
+			forEachRouterAddress(func(routerAddr addresses.Address) {
 				Eventually(func() error {
 					_, err := sendAndReceive(routerAddr, externalPort1)
 					return err
@@ -58,7 +58,7 @@ var _ = Describe("Tcp Routing", func() {
 				resp, err := sendAndReceive(routerAddr, externalPort1)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(resp).To(ContainSubstring(serverId1))
-			}
+			})
 		})
 
 		Context("single external port to two different apps", func() {
@@ -70,10 +70,10 @@ var _ = Describe("Tcp Routing", func() {
 			BeforeEach(func() {
 				secondAppName = routing_helpers.GenerateAppName()
 				serverId2 = "server2"
-				cmd := fmt.Sprintf("tcp-droplet-receiver --serverId=%s", serverId2)
+				cmd := fmt.Sprintf("tcp-receiver --serverId=%s --format=json", serverId2)
 
 				// Uses --no-route flag so there is no HTTP route
-				routing_helpers.PushAppNoStart(secondAppName, tcpDropletReceiver, routingConfig.GoBuildpackName, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+				routing_helpers.PushAppNoStart(secondAppName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
 				routing_helpers.MapRouteToAppWithPort(secondAppName, domainName, externalPort1, DEFAULT_TIMEOUT)
 				routing_helpers.UpdateTCPPort(secondAppName, externalPort1, []uint16{3333}, DEFAULT_TIMEOUT)
 				routing_helpers.StartApp(secondAppName, DEFAULT_TIMEOUT)
@@ -86,7 +86,7 @@ var _ = Describe("Tcp Routing", func() {
 			})
 
 			It("maps single external port to both applications", func() {
-				for _, routerAddr := range routingConfig.Addresses {
+				forEachRouterAddress(func(routerAddr addresses.Address) {
 					Eventually(func() error {
 						_, err := sendAndReceive(routerAddr, externalPort1)
 						return err
@@ -95,15 +95,15 @@ var _ = Describe("Tcp Routing", func() {
 					serverResponses := func() []string {
 						var servers []string
 						for i := 0; i < 10; i++ {
-							srv, err := getServerResponse(routerAddr, externalPort1)
+							identity, err := getIdentity(routerAddr, externalPort1)
 							Expect(err).ToNot(HaveOccurred())
-							servers = append(servers, srv)
+							servers = append(servers, identity.ServerID)
 						}
 						return servers
 					}
 					Expect(serverResponses()).To(ContainElement(serverId1))
 					Expect(serverResponses()).To(ContainElement(serverId2))
-				}
+				})
 			})
 		})
 
@@ -119,7 +119,7 @@ var _ = Describe("Tcp Routing", func() {
 			})
 
 			It("routes traffic from two external ports to the app", func() {
-				for _, routerAddr := range routingConfig.Addresses {
+				forEachRouterAddress(func(routerAddr addresses.Address) {
 					Eventually(func() string {
 						serverId, _ := sendAndReceive(routerAddr, externalPort1)
 						return serverId
@@ -129,22 +129,73 @@ var _ = Describe("Tcp Routing", func() {
 						serverId, _ := sendAndReceive(routerAddr, externalPort2)
 						return serverId
 					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(serverId1))
-				}
+				})
 			})
 		})
 
 	})
 
+	Context("multiple app instances", func() {
+		const instances = 2
+
+		var (
+			appName      string
+			serverId     string
+			externalPort uint16
+		)
+
+		BeforeEach(func() {
+			appName = routing_helpers.GenerateAppName()
+			serverId = "instances-server"
+			cmd := fmt.Sprintf("tcp-receiver --serverId=%s --format=json", serverId)
+			spaceName := environment.RegularUserContext().Space
+			externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+			// Uses --no-route flag so there is no HTTP route
+			routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process", "-i", fmt.Sprintf("%d", instances))
+			routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+			routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+		})
+
+		AfterEach(func() {
+			routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+			routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+		})
+
+		It("routes connections to every instance of the app", func() {
+			forEachRouterAddress(func(routerAddr addresses.Address) {
+				seen := map[int]receiver.Identity{}
+				Eventually(func() map[int]receiver.Identity {
+					identity, err := getIdentity(routerAddr, externalPort)
+					if err == nil {
+						Expect(identity.InstanceIndex).NotTo(BeNil(), "the receiver did not report CF_INSTANCE_INDEX")
+						seen[*identity.InstanceIndex] = identity
+					}
+					return seen
+				}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveLen(instances))
+
+				guids := map[string]bool{}
+				for index, identity := range seen {
+					Expect(index).To(BeNumerically("<", instances))
+					Expect(identity.ServerID).To(Equal(serverId))
+					Expect(identity.InstanceIP).NotTo(BeEmpty())
+					guids[identity.InstanceGUID] = true
+				}
+				Expect(guids).To(HaveLen(instances), "every instance should report its own CF_INSTANCE_GUID")
+			})
+		})
+	})
+
 	Context("multiple-app ports", func() {
 
 		var (
-			appName           string
-			tcpSampleReceiver = assets.NewAssets().TcpSampleReceiver
-			serverId1         string
-			externalPort1     uint16
-			appPort1          uint16
-			appPort2          uint16
-			spaceName         string
+			appName       string
+			serverId1     string
+			externalPort1 uint16
+			appPort1      uint16
+			appPort2      uint16
+			spaceName     string
 		)
 
 		BeforeEach(func() {
@@ -152,12 +203,12 @@ var _ = Describe("Tcp Routing", func() {
 			serverId1 = "server1"
 			appPort1 = 3434
 			appPort2 = 3535
-			cmd := fmt.Sprintf("tcp-sample-receiver --address=0.0.0.0:%d,0.0.0.0:%d --serverId=%s", appPort1, appPort2, serverId1)
+			cmd := fmt.Sprintf("tcp-receiver --address=0.0.0.0:%d,0.0.0.0:%d --serverId=%s --format=json", appPort1, appPort2, serverId1)
 			spaceName = environment.RegularUserContext().Space
 			externalPort1 = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
 
 			// Uses --no-route flag so there is no HTTP route
-			routing_helpers.PushAppNoStart(appName, tcpSampleReceiver, routingConfig.GoBuildpackName, "", 2*time.Minute, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+			routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", 2*time.Minute, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
 			routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort1, DEFAULT_TIMEOUT)
 			routing_helpers.UpdateTCPPort(appName, externalPort1, []uint16{appPort1}, DEFAULT_TIMEOUT)
 			routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
@@ -176,64 +227,101 @@ var _ = Describe("Tcp Routing", func() {
 
 			It("should switch between ports", func() {
 
-				for _, routerAddr := range routingConfig.Addresses {
+				forEachRouterAddress(func(routerAddr addresses.Address) {
 					Eventually(func() error {
 						_, err := sendAndReceive(routerAddr, externalPort1)
 						return err
 					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
 
 					Eventually(func() (string, error) {
-						return sendAndReceive(routerAddr, externalPort1)
-					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(fmt.Sprintf("%d", appPort1)))
+						return getListenAddress(routerAddr, externalPort1)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveSuffix(fmt.Sprintf(":%d", appPort1)))
 
 					Eventually(func() (string, error) {
-						return sendAndReceive(routerAddr, externalPort1)
-					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(ContainSubstring(fmt.Sprintf("%d", appPort2)))
-				}
+						return getListenAddress(routerAddr, externalPort1)
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).Should(HaveSuffix(fmt.Sprintf(":%d", appPort2)))
+				})
 			})
 		})
 
 		Context("multiple external ports with multiple app ports", func() {
 			var (
 				externalPort2 uint16
+				adminToken    string
 			)
 
 			BeforeEach(func() {
 				externalPort2 = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
 				routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort2, DEFAULT_TIMEOUT)
 				routing_helpers.UpdateTCPPort(appName, externalPort2, []uint16{appPort2}, DEFAULT_TIMEOUT)
+				adminToken = receiver.NewAdminToken()
+				Eventually(cf.Cf("set-env", appName, receiver.ADMIN_TOKEN_ENV, adminToken), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
 				routing_helpers.RestartApp(appName, DEFAULT_TIMEOUT)
 			})
 
+			It("delivers traffic for each external port only to its app port", func() {
+				// The receiver serves its admin API on $PORT, reached through an HTTP route.
+				Eventually(cf.Cf("map-route", appName, routingConfig.AppsDomain, "--hostname", appName), DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+				admin, stopForwarding, err := receiver.ConnectAdmin(appName, fmt.Sprintf("%s.%s", appName, routingConfig.AppsDomain), adminToken, DEFAULT_TIMEOUT)
+				Expect(err).NotTo(HaveOccurred())
+				DeferCleanup(stopForwarding)
+
+				listener1 := fmt.Sprintf("0.0.0.0:%d", appPort1)
+				listener2 := fmt.Sprintf("0.0.0.0:%d", appPort2)
+				messages := 3
+
+				forEachRouterAddress(func(routerAddr addresses.Address) {
+					Eventually(func() error {
+						_, err := sendAndReceive(routerAddr, externalPort2)
+						return err
+					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+
+					before, err := admin.Metrics()
+					Expect(err).NotTo(HaveOccurred())
+
+					for i := 0; i < messages; i++ {
+						_, err := sendAndReceive(routerAddr, externalPort2)
+						Expect(err).NotTo(HaveOccurred())
+					}
+
+					after, err := admin.Metrics()
+					Expect(err).NotTo(HaveOccurred())
+					AddReportEntry(fmt.Sprintf("Receiver metrics after routing through %s", routerAddr), after)
+
+					Expect(after.Listeners[listener2].Messages - before.Listeners[listener2].Messages).To(BeEquivalentTo(messages))
+					Expect(after.Listeners[listener1]).To(Equal(before.Listeners[listener1]))
+				})
+			})
+
 			It("should map first external port to the first app port", func() {
 
-				for _, routerAddr := range routingConfig.Addresses {
+				forEachRouterAddress(func(routerAddr addresses.Address) {
 					var (
-						resp string
-						err  error
+						identity receiver.Identity
+						err      error
 					)
 					Eventually(func() error {
-						resp, err = sendAndReceive(routerAddr, externalPort1)
+						identity, err = getIdentity(routerAddr, externalPort1)
 						return err
 					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
 
-					Expect(resp).To(ContainSubstring(fmt.Sprintf("%d", appPort1)))
-				}
+					Expect(identity.ListenAddress).To(HaveSuffix(fmt.Sprintf(":%d", appPort1)))
+				})
 			})
 
 			It("should map second external port to the second app port", func() {
-				for _, routerAddr := range routingConfig.Addresses {
+				forEachRouterAddress(func(routerAddr addresses.Address) {
 					var (
-						resp string
-						err  error
+						identity receiver.Identity
+						err      error
 					)
 					Eventually(func() error {
-						resp, err = sendAndReceive(routerAddr, externalPort2)
+						identity, err = getIdentity(routerAddr, externalPort2)
 						return err
 					}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
 
-					Expect(resp).To(ContainSubstring(fmt.Sprintf("%d", appPort2)))
-				}
+					Expect(identity.ListenAddress).To(HaveSuffix(fmt.Sprintf(":%d", appPort2)))
+				})
 			})
 		})
 	})
@@ -247,20 +335,29 @@ const (
 	BUFFER_SIZE             = 1024
 )
 
-func getServerResponse(addr string, externalPort uint16) (string, error) {
+// forEachRouterAddress runs check against every router address in turn, and
+// records whether it passed for that address and IP family in the report.
+func forEachRouterAddress(check func(routerAddr addresses.Address)) {
+	helpers.ForEachAddress(routerAddresses, "Routing through", check)
+}
+
+// getIdentity sends a message to a receiver running with --format=json and
+// decodes its response.
+func getIdentity(addr addresses.Address, externalPort uint16) (receiver.Identity, error) {
 	response, err := sendAndReceive(addr, externalPort)
 	if err != nil {
-		return "", err
-	}
-	tokens := strings.Split(response, ":")
-	if len(tokens) == 0 {
-		return "", errors.New("Could not extract server id from response")
+		return receiver.Identity{}, err
 	}
-	return tokens[0], nil
+	return receiver.DecodeIdentity([]byte(response))
+}
+
+func getListenAddress(addr addresses.Address, externalPort uint16) (string, error) {
+	identity, err := getIdentity(addr, externalPort)
+	return identity.ListenAddress, err
 }
 
-func sendAndReceive(addr string, externalPort uint16) (string, error) {
-	address := fmt.Sprintf("%s:%d", addr, externalPort)
+func sendAndReceive(addr addresses.Address, externalPort uint16) (string, error) {
+	address := addr.JoinPort(externalPort)
 
 	conn, err := net.DialTimeout(CONN_TYPE, address, DEFAULT_CONNECT_TIMEOUT)
 	if err != nil {
@@ -309,5 +406,5 @@ func sendAndReceive(addr string, externalPort uint16) (string, error) {
 
 	logger.Info("read-message", lager.Data{"address": conn.RemoteAddr(), "message": string(buff[:n])})
 
-	return string(buff), conn.Close()
+	return string(buff[:n]), conn.Close()
 }
@@ -0,0 +1,84 @@
+package tcp_routing_test
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	routing_helpers "code.cloudfoundry.org/cf-routing-test-helpers/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/addresses"
+	"code.cloudfoundry.org/routing-acceptance-tests/helpers/tcpclient"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tcp Routing TLS passthrough", func() {
+	const alpnProtocol = "rats-test/1"
+
+	var (
+		appName      string
+		serverId     string
+		serverName   string
+		externalPort uint16
+	)
+
+	BeforeEach(func() {
+		helpers.UpdateOrgQuota(adminContext)
+
+		appName = routing_helpers.GenerateAppName()
+		serverId = "tls-server"
+		serverName = fmt.Sprintf("%s.%s", appName, domainName)
+		cmd := fmt.Sprintf("tcp-receiver --address=0.0.0.0:3333 --serverId=%s --tls --tlsSAN=%s", serverId, serverName)
+		spaceName := environment.RegularUserContext().Space
+		externalPort = routing_helpers.CreateTcpRouteWithRandomPort(spaceName, domainName, DEFAULT_TIMEOUT)
+
+		// Uses --no-route flag so there is no HTTP route
+		routing_helpers.PushAppNoStart(appName, pushAssets.TcpReceiver, pushAssets.Buildpack, "", CF_PUSH_TIMEOUT, "256M", "-c", cmd, "--no-route", "-s", "cflinuxfs4", "-u", "process")
+		routing_helpers.MapRouteToAppWithPort(appName, domainName, externalPort, DEFAULT_TIMEOUT)
+		routing_helpers.UpdateTCPPort(appName, externalPort, []uint16{3333}, DEFAULT_TIMEOUT)
+		routing_helpers.StartApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	AfterEach(func() {
+		routing_helpers.AppReport(appName, DEFAULT_TIMEOUT)
+		routing_helpers.DeleteApp(appName, DEFAULT_TIMEOUT)
+	})
+
+	It("completes the TLS handshake with the app through every router address", func() {
+		forEachRouterAddress(func(routerAddr addresses.Address) {
+			address := routerAddr.JoinPort(externalPort)
+			config := &tls.Config{
+				ServerName: serverName,
+				NextProtos: []string{alpnProtocol, "h2"},
+				// The app's certificate is self-signed; it is verified below.
+				InsecureSkipVerify: true,
+			}
+
+			var conn *tls.Conn
+			Eventually(func() error {
+				var err error
+				conn, err = tcpclient.DialTLS(address, config, DEFAULT_CONNECT_TIMEOUT)
+				return err
+			}, DEFAULT_TIMEOUT, DEFAULT_POLLING_INTERVAL).ShouldNot(HaveOccurred())
+			defer conn.Close()
+
+			state := conn.ConnectionState()
+			Expect(state.PeerCertificates).ToNot(BeEmpty())
+			presented := state.PeerCertificates[0]
+			Expect(tcpclient.VerifySelfSigned(presented, serverName)).To(Succeed())
+			Expect(state.NegotiatedProtocol).To(Equal(alpnProtocol))
+
+			message := []byte(fmt.Sprintf("Time is %d", time.Now().Nanosecond()))
+			resp, err := tcpclient.Exchange(conn, message, DEFAULT_RW_TIMEOUT)
+			Expect(err).ToNot(HaveOccurred())
+			AddReportEntry(fmt.Sprintf("TLS response through %s", routerAddr), string(resp))
+
+			Expect(string(resp)).To(HavePrefix(serverId + ":"))
+			Expect(tcpclient.ReportedField(resp, "cert_sha256")).To(Equal(tcpclient.Fingerprint(presented)), "the certificate presented is not the app's")
+			Expect(tcpclient.ReportedField(resp, "sni")).To(Equal(serverName))
+			Expect(tcpclient.ReportedField(resp, "alpn")).To(Equal(alpnProtocol))
+		})
+	})
+})